@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsondoc"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+func runApply(ctx context.Context, e env, args []string) int {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: libra apply [flags] DOCUMENT PATCH")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Apply the JSON diffs or the JSON Patch which is written by libra diff onto a JSON document, use - to read one of them from the standard input.")
+		fmt.Fprintln(fs.Output(), "The old values are checked before any change is applied, the JSON Patch should be written with -test to check the replaced and removed values.")
+		fmt.Fprintln(fs.Output(), "The exit code is 1 when the patch is conflicted with the document.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	outputName := fs.String("o", "", "write the patched document into the file instead of the standard output")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	if fs.Arg(0) == stdinName && fs.Arg(1) == stdinName {
+		fmt.Fprintln(e.stderr, "libra apply: only one of the document and the patch could be read from the standard input")
+		return exitError
+	}
+
+	doc, err := readDocument(e, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra apply: %s\n", err.Error())
+		return exitError
+	}
+
+	diffs, err := readPatch(e, fs.Arg(1), doc)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra apply: %s\n", err.Error())
+		return exitError
+	}
+
+	result, err := jsondoc.Patch(ctx, doc, diffs)
+	if err != nil {
+		conflictErr := &patcher.ConflictError{}
+		if errors.As(err, &conflictErr) {
+			for _, c := range conflictErr.Conflicts {
+				actual := encodeValue(c.Actual)
+				if _, ok := jsondoc.Lookup(doc, c.Field); !ok {
+					actual = "not found"
+				}
+				fmt.Fprintf(e.stderr, "libra apply: conflict on %s: expected %s, got %s\n", fieldName(c.Field), encodeValue(c.Expected), actual)
+			}
+
+			return exitDifferent
+		}
+
+		fmt.Fprintf(e.stderr, "libra apply: %s\n", err.Error())
+		return exitError
+	}
+
+	if err := writeDocument(e, *outputName, result); err != nil {
+		fmt.Fprintf(e.stderr, "libra apply: %s\n", err.Error())
+		return exitError
+	}
+
+	return exitEqual
+}
+
+//readPatch reads the JSON diffs or the JSON Patch operations which are applied onto the document
+func readPatch(e env, name string, doc interface{}) ([]diff.Diff, error) {
+	var r io.Reader = e.stdin
+	if name != stdinName {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	elements := []map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, fmt.Errorf("error on read %s Error : the patch should be an array of the JSON diffs or the JSON Patch operations", name)
+	}
+
+	if len(elements) == 0 {
+		return []diff.Diff{}, nil
+	}
+
+	if _, ok := elements[0]["op"]; ok {
+		ops := []codec.JSONPatchOperation{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&ops); err != nil {
+			return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+		}
+
+		diffs, err := jsondoc.DecodeJSONPatch(doc, ops)
+		if err != nil {
+			return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+		}
+
+		return diffs, nil
+	}
+
+	encoded := []codec.JSONDiff{}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	diffs, err := codec.DecodeJSONDiffs(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	return diffs, nil
+}
+
+func writeDocument(e env, name string, doc interface{}) error {
+	b := &bytes.Buffer{}
+	if err := writeJSON(b, doc); err != nil {
+		return err
+	}
+
+	if name == "" {
+		_, err := e.stdout.Write(b.Bytes())
+		return err
+	}
+
+	if err := os.WriteFile(name, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error on write %s Error : %s", name, err.Error())
+	}
+
+	return nil
+}
+
+func fieldName(field string) string {
+	if field == "" {
+		return "the document"
+	}
+
+	return field
+}
+
+func encodeValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(b)
+}
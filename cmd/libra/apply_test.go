@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Apply(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.json", `{"name": "app", "debug": false, "port": 0, "tags": ["a", "b"]}`)
+	new := writeFile(t, dir, "new.json", `{"name": "web", "debug": true, "port": "", "tags": ["a"], "tls": {"cert": "a.pem"}}`)
+	patched := "{\n  \"debug\": true,\n  \"name\": \"web\",\n  \"port\": \"\",\n  \"tags\": [\n    \"a\"\n  ],\n  \"tls\": {\n    \"cert\": \"a.pem\"\n  }\n}\n"
+
+	patches := map[string]string{}
+	for _, format := range []string{"json", "jsonpatch"} {
+		for _, args := range [][]string{{"-format", format}, {"-format", format, "-test"}} {
+			stdout := &bytes.Buffer{}
+			if got := run(context.Background(), env{strings.NewReader(""), stdout, &bytes.Buffer{}}, append(append([]string{"diff"}, args...), old, new)); got != exitDifferent {
+				t.Fatalf("run() = %v, want %v", got, exitDifferent)
+			}
+			patches[strings.Join(args, " ")] = writeFile(t, dir, strings.Join(args, "")+".json", stdout.String())
+		}
+	}
+	invalid := writeFile(t, dir, "invalid.json", `{"op": "add"}`)
+	unsupported := writeFile(t, dir, "unsupported.json", `[{"op": "move", "from": "/a", "path": "/b"}]`)
+	changeType := writeFile(t, dir, "changetype.json", `[{"change_type": "moved", "field": "a"}]`)
+	output := filepath.Join(dir, "output.json")
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"succeed when apply the JSON diffs",
+			[]string{"apply", old, patches["-format json"]},
+			"",
+			exitEqual,
+			patched,
+			"",
+		}, {
+			"succeed when apply the JSON Patch",
+			[]string{"apply", old, patches["-format jsonpatch"]},
+			"",
+			exitEqual,
+			patched,
+			"",
+		}, {
+			"succeed when apply the JSON Patch with test",
+			[]string{"apply", old, patches["-format jsonpatch -test"]},
+			"",
+			exitEqual,
+			patched,
+			"",
+		}, {
+			"succeed when read the document from stdin",
+			[]string{"apply", "-", patches["-format json -test"]},
+			`{"tags": ["a", "b"], "port": 0, "debug": false, "name": "app"}`,
+			exitEqual,
+			patched,
+			"",
+		}, {
+			"succeed when apply the empty patch",
+			[]string{"apply", old, "-"},
+			`[]`,
+			exitEqual,
+			"{\n  \"debug\": false,\n  \"name\": \"app\",\n  \"port\": 0,\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}\n",
+			"",
+		}, {
+			"failed when the JSON diffs are conflicted",
+			[]string{"apply", new, patches["-format json"]},
+			"",
+			exitDifferent,
+			"",
+			"libra apply: conflict on debug: expected false, got true\n",
+		}, {
+			"failed when the JSON Patch is conflicted",
+			[]string{"apply", "-", patches["-format jsonpatch -test"]},
+			`{"name": "api", "debug": false, "port": 0, "tags": ["a", "b"]}`,
+			exitDifferent,
+			"",
+			"libra apply: conflict on name: expected \"app\", got \"api\"\n",
+		}, {
+			"failed when the JSON Patch path is not found",
+			[]string{"apply", "-", patches["-format jsonpatch"]},
+			`{"name": "app", "debug": false, "port": 0, "tags": ["a"]}`,
+			exitDifferent,
+			"",
+			"libra apply: conflict on tags.1: expected null, got not found\n",
+		}, {
+			"failed when the patch is not an array",
+			[]string{"apply", old, invalid},
+			"",
+			exitError,
+			"",
+			"should be an array",
+		}, {
+			"failed when the operation is unsupported",
+			[]string{"apply", old, unsupported},
+			"",
+			exitError,
+			"",
+			"unsupported operation move",
+		}, {
+			"failed when the change type is unsupported",
+			[]string{"apply", old, changeType},
+			"",
+			exitError,
+			"",
+			`unsupported change type "moved"`,
+		}, {
+			"failed when the arguments are missing",
+			[]string{"apply", old},
+			"",
+			exitError,
+			"",
+			"Usage: libra apply",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(tt.stdin), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("succeed when write the output file", func(t *testing.T) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		if got := run(context.Background(), env{strings.NewReader(""), stdout, stderr}, []string{"apply", "-o", output, old, patches["-format json"]}); got != exitEqual {
+			t.Fatalf("run() = %v, want %v, stderr %s", got, exitEqual, stderr)
+		}
+
+		b, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		if string(b) != patched || stdout.Len() > 0 {
+			t.Errorf("run() output = %q, stdout = %q, want %q", b, stdout, patched)
+		}
+	})
+}
+
+func TestRun_ApplyDottedKeys(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.json", `{"a.b": 1, "a": {"b": 1}}`)
+	new := writeFile(t, dir, "new.json", `{"a.b": 2, "a": {"b": 1}}`)
+	patched := "{\n  \"a\": {\n    \"b\": 1\n  },\n  \"a.b\": 2\n}\n"
+
+	stdout := &bytes.Buffer{}
+	if got := run(context.Background(), env{strings.NewReader(""), stdout, &bytes.Buffer{}}, []string{"diff", old, new}); got != exitDifferent {
+		t.Fatalf("run() = %v, want %v", got, exitDifferent)
+	}
+	if want := "~ new.json\n  ~ a.b: 1 -> 2\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout, want)
+	}
+
+	for _, format := range []string{"json", "jsonpatch"} {
+		t.Run(format, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			if got := run(context.Background(), env{strings.NewReader(""), stdout, &bytes.Buffer{}}, []string{"diff", "-format", format, old, new}); got != exitDifferent {
+				t.Fatalf("run() = %v, want %v", got, exitDifferent)
+			}
+			patch := writeFile(t, dir, format+".json", stdout.String())
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			if got := run(context.Background(), env{strings.NewReader(""), stdout, stderr}, []string{"apply", old, patch}); got != exitEqual {
+				t.Errorf("run() = %v, want %v, stderr %s", got, exitEqual, stderr)
+			}
+			if stdout.String() != patched {
+				t.Errorf("run() stdout = %q, want %q", stdout, patched)
+			}
+		})
+	}
+}
+
+func TestRun_ApplyLargeIntegers(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.json", `{"id": 9007199254740993, "a": 1, "ratio": 1.50}`)
+	patches := map[string]string{
+		"JSON Patch": writeFile(t, dir, "jsonpatch.json", `[{"op": "replace", "path": "/a", "value": 9007199254740995}]`),
+		"JSON diffs": writeFile(t, dir, "json.json", `[{"change_type": "changed", "field": "a", "old": 1.0, "new": 9007199254740995}]`),
+	}
+	want := "{\n  \"a\": 9007199254740995,\n  \"id\": 9007199254740993,\n  \"ratio\": 1.50\n}\n"
+
+	for name, patch := range patches {
+		t.Run(name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			if got := run(context.Background(), env{strings.NewReader(""), stdout, stderr}, []string{"apply", old, patch}); got != exitEqual {
+				t.Errorf("run() = %v, want %v, stderr %s", got, exitEqual, stderr)
+			}
+			if stdout.String() != want {
+				t.Errorf("run() stdout = %q, want %q", stdout, want)
+			}
+		})
+	}
+}
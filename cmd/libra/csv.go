@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/table"
+)
+
+//stringList is a flag which could be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func runCSV(ctx context.Context, e env, args []string) int {
+	fs := flag.NewFlagSet("csv", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: libra csv [flags] OLD NEW")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Compare two CSV files as tables which rows are matched by the key columns, use - to read one of them from the standard input.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	out := &output{}
+	out.register(fs)
+	keys := fs.String("key", "id", "comma-separated key columns, e.g. region,id")
+	types := fs.String("type", "", "comma-separated types of the columns, e.g. amount=number,paid_at=date. The other columns are compared as string")
+	layouts := &stringList{}
+	fs.Var(layouts, "date-layout", "Go time layout of the date columns, could be given more than once (default RFC 3339 and 2006-01-02)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	if err := out.validate(); err != nil {
+		fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+		return exitError
+	}
+
+	if fs.Arg(0) == stdinName && fs.Arg(1) == stdinName {
+		fmt.Fprintln(e.stderr, "libra csv: only one of the tables could be read from the standard input")
+		return exitError
+	}
+
+	opts := table.Options{Keys: strings.Split(*keys, ","), DateLayouts: *layouts}
+	columnTypes, err := parseColumnTypes(*types)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+		return exitError
+	}
+	opts.Types = columnTypes
+
+	old, err := readTable(e, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+		return exitError
+	}
+
+	new, err := readTable(e, fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+		return exitError
+	}
+
+	diffs, err := table.Compare(ctx, old, new, opts)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+		return exitError
+	}
+
+	objectType := documentName(fs.Arg(1))
+	w := newRecordWriter(out, e.stdout, "rows")
+	for start := 0; start < len(diffs); {
+		end := start + 1
+		for end < len(diffs) && diffs[end].ObjectID == diffs[start].ObjectID {
+			end++
+		}
+
+		row := diffs[start:end]
+		for i := range row {
+			row[i].ObjectType = objectType
+		}
+
+		if err := w.write(ctx, row); err != nil {
+			fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+			return exitError
+		}
+		start = end
+	}
+
+	if err := w.close(); err != nil {
+		fmt.Fprintf(e.stderr, "libra csv: %s\n", err.Error())
+		return exitError
+	}
+
+	if len(diffs) > 0 {
+		return exitDifferent
+	}
+
+	return exitEqual
+}
+
+func parseColumnTypes(s string) (map[string]table.ColumnType, error) {
+	types := map[string]table.ColumnType{}
+	if s == "" {
+		return types, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		column, columnType, ok := strings.Cut(pair, "=")
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid column type %q, expected column=type", pair)
+		}
+
+		switch table.ColumnType(columnType) {
+		case table.String, table.Number, table.Date:
+			types[column] = table.ColumnType(columnType)
+		default:
+			return nil, fmt.Errorf("unsupported type %q of column %s, expected string, number or date", columnType, column)
+		}
+	}
+
+	return types, nil
+}
+
+func readTable(e env, name string) (*table.Table, error) {
+	if name == stdinName {
+		t, err := table.ReadCSV(e.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error on read stdin Error : %s", err.Error())
+		}
+
+		return t, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+	defer f.Close()
+
+	t, err := table.ReadCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	return t, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_CSV(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.csv", "id,name,amount,paid_at\n1,a,10.50,2024-01-02\n2,b,20,\n")
+	new := writeFile(t, dir, "new.csv", "id,name,amount,paid_at\n3,c,5,\n1,A,10.5,02/01/2024\n")
+	dottedOld := writeFile(t, dir, "dotted-old.csv", "id,price.usd\n1,10\n")
+	dottedNew := writeFile(t, dir, "dotted-new.csv", "id,price.usd\n1,11\n")
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"succeed when write the text",
+			[]string{"csv", "-type", "amount=number,paid_at=date", "-date-layout", "2006-01-02", "-date-layout", "02/01/2006", old, new},
+			"",
+			exitDifferent,
+			"~ new.csv#1\n  ~ name: \"a\" -> \"A\"\n- new.csv#2: map[amount:20 id:2 name:b paid_at:]\n+ new.csv#3: map[amount:5 id:3 name:c paid_at:]\n\n1 added, 1 removed, 1 changed rows\n",
+			"",
+		}, {
+			"succeed when compare the cells as string",
+			[]string{"csv", "-format", "json", old, "-"},
+			"id,name,amount,paid_at\n2,b,20,\n1,a,10.5,2024-01-02\n",
+			exitDifferent,
+			"[\n  {\n    \"change_type\": \"changed\",\n    \"object_type\": \"stdin\",\n    \"object_id\": \"1\",\n    \"field\": \"amount\",\n    \"old\": \"10.50\",\n    \"new\": \"10.5\"\n  }\n]\n",
+			"",
+		}, {
+			"succeed when the column name contains dots",
+			[]string{"csv", "-format", "jsonpatch", dottedOld, dottedNew},
+			"",
+			exitDifferent,
+			"{\n  \"1\": [\n    {\n      \"op\": \"replace\",\n      \"path\": \"/price.usd\",\n      \"value\": \"11\"\n    }\n  ]\n}\n",
+			"",
+		}, {
+			"succeed when the tables are equal",
+			[]string{"csv", "-key", "name,id", "-type", "amount=number", old, "-"},
+			"name,id,paid_at,amount\nb,2,,20.0\na,1,2024-01-02,10.5\n",
+			exitEqual,
+			"",
+			"",
+		}, {
+			"failed when the type is unsupported",
+			[]string{"csv", "-type", "amount=money", old, new},
+			"",
+			exitError,
+			"",
+			`unsupported type "money" of column amount`,
+		}, {
+			"failed when the type is invalid",
+			[]string{"csv", "-type", "amount", old, new},
+			"",
+			exitError,
+			"",
+			`invalid column type "amount"`,
+		}, {
+			"failed when the date is invalid",
+			[]string{"csv", "-type", "paid_at=date", old, new},
+			"",
+			exitError,
+			"",
+			`invalid date "02/01/2024" of column paid_at`,
+		}, {
+			"failed when the key column is not found",
+			[]string{"csv", "-key", "code", old, new},
+			"",
+			exitError,
+			"",
+			"key column code is not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(tt.stdin), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
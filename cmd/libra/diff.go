@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsondoc"
+	"github.com/haritsfahreza/libra/pkg/render"
+)
+
+//stdinName is the argument which reads the document from the standard input
+const stdinName = "-"
+
+//formats are the supported output formats
+var formats = []string{"text", "json", "jsonpatch", "markdown"}
+
+//output is the options of writing the diffs
+type output struct {
+	format string
+	color  bool
+	test   bool
+}
+
+func (o *output) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.format, "format", "text", "output format: text, json, jsonpatch or markdown")
+	fs.BoolVar(&o.color, "color", false, "colorize the text output")
+	fs.BoolVar(&o.test, "test", false, "write a test operation of the old value before each replace and remove of the jsonpatch output")
+}
+
+func (o *output) validate() error {
+	for _, format := range formats {
+		if o.format == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported format %q", o.format)
+}
+
+func (o *output) write(ctx context.Context, w io.Writer, diffs []diff.Diff) error {
+	switch o.format {
+	case "json":
+		encoded, err := codec.EncodeJSONDiffs(diffs)
+		if err != nil {
+			return err
+		}
+
+		return writeJSON(w, encoded)
+	case "jsonpatch":
+		return writeJSON(w, codec.EncodeJSONPatch(diffs, o.test))
+	case "markdown":
+		return (&render.MarkdownRenderer{}).Render(ctx, w, diffs)
+	default:
+		return (&render.TextRenderer{Color: o.color}).Render(ctx, w, diffs)
+	}
+}
+
+func runDiff(ctx context.Context, e env, args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: libra diff [flags] OLD NEW")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Compare two JSON documents, use - to read one of them from the standard input.")
+		fmt.Fprintln(fs.Output(), "The files of two directories are matched by their relative paths and compared recursively.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	out := &output{}
+	out.register(fs)
+	include := fs.String("include", "*.json", "pattern of the file names which are compared within the directories")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	if err := out.validate(); err != nil {
+		fmt.Fprintf(e.stderr, "libra diff: %s\n", err.Error())
+		return exitError
+	}
+
+	if _, err := path.Match(*include, ""); err != nil {
+		fmt.Fprintf(e.stderr, "libra diff: invalid include pattern %q\n", *include)
+		return exitError
+	}
+
+	if isDir(fs.Arg(0)) || isDir(fs.Arg(1)) {
+		return runDirDiff(ctx, e, out, fs.Arg(0), fs.Arg(1), *include)
+	}
+
+	if fs.Arg(0) == stdinName && fs.Arg(1) == stdinName {
+		fmt.Fprintln(e.stderr, "libra diff: only one of the documents could be read from the standard input")
+		return exitError
+	}
+
+	diffs, err := diffFiles(ctx, e, fs.Arg(0), fs.Arg(1), documentName(fs.Arg(1)))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra diff: %s\n", err.Error())
+		return exitError
+	}
+
+	if err := out.write(ctx, e.stdout, diffs); err != nil {
+		fmt.Fprintf(e.stderr, "libra diff: %s\n", err.Error())
+		return exitError
+	}
+
+	if len(diffs) > 0 {
+		return exitDifferent
+	}
+
+	return exitEqual
+}
+
+func runDirDiff(ctx context.Context, e env, out *output, oldDir, newDir, include string) int {
+	if !isDir(oldDir) || !isDir(newDir) {
+		fmt.Fprintln(e.stderr, "libra diff: a directory could only be compared with another directory")
+		return exitError
+	}
+
+	results, err := diffDirs(ctx, e, oldDir, newDir, include)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra diff: %s\n", err.Error())
+		return exitError
+	}
+
+	if err := out.writeFiles(ctx, e.stdout, results); err != nil {
+		fmt.Fprintf(e.stderr, "libra diff: %s\n", err.Error())
+		return exitError
+	}
+
+	if differs(results) {
+		return exitDifferent
+	}
+
+	return exitEqual
+}
+
+//diffFiles compares two JSON documents, the diffs are named after the object type
+func diffFiles(ctx context.Context, e env, oldName, newName, objectType string) ([]diff.Diff, error) {
+	old, err := readDocument(e, oldName)
+	if err != nil {
+		return nil, err
+	}
+
+	new, err := readDocument(e, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := jsondoc.Compare(ctx, old, new)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range diffs {
+		diffs[i].ObjectType = objectType
+	}
+
+	return diffs, nil
+}
+
+func readDocument(e env, name string) (interface{}, error) {
+	if name == stdinName {
+		v, err := jsondoc.Decode(e.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error on read stdin Error : %s", err.Error())
+		}
+
+		return v, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+	defer f.Close()
+
+	v, err := jsondoc.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	return v, nil
+}
+
+func documentName(name string) string {
+	if name == stdinName {
+		return "stdin"
+	}
+
+	return filepath.Base(name)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(v)
+}
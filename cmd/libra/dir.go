@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/render"
+)
+
+const (
+	statusAdded     = "added"
+	statusRemoved   = "removed"
+	statusChanged   = "changed"
+	statusUnchanged = "unchanged"
+)
+
+//fileResult is the comparison of a file which is matched by its relative path in both directories
+type fileResult struct {
+	Path    string      `json:"path"`
+	Status  string      `json:"status"`
+	Changes int         `json:"changes,omitempty"`
+	Diffs   []diff.Diff `json:"-"`
+}
+
+//diffDirs compares the files of two directories recursively, the results are ordered by path.
+//The diffs of an added or a removed file contain the whole document, the others are named after the relative path
+func diffDirs(ctx context.Context, e env, oldDir, newDir, include string) ([]fileResult, error) {
+	oldFiles, err := listFiles(oldDir, include)
+	if err != nil {
+		return nil, err
+	}
+
+	newFiles, err := listFiles(newDir, include)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(newFiles))
+	for p := range newFiles {
+		paths = append(paths, p)
+	}
+	for p := range oldFiles {
+		if _, ok := newFiles[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	results := make([]fileResult, 0, len(paths))
+	for _, p := range paths {
+		oldFile, inOld := oldFiles[p]
+		newFile, inNew := newFiles[p]
+
+		result := fileResult{Path: p}
+		switch {
+		case !inOld:
+			doc, err := readDocument(e, newFile)
+			if err != nil {
+				return nil, err
+			}
+			result.Status = statusAdded
+			result.Diffs = []diff.Diff{{ChangeType: diff.New, ObjectType: p, New: doc}}
+		case !inNew:
+			doc, err := readDocument(e, oldFile)
+			if err != nil {
+				return nil, err
+			}
+			result.Status = statusRemoved
+			result.Diffs = []diff.Diff{{ChangeType: diff.Removed, ObjectType: p, Old: doc}}
+		default:
+			diffs, err := diffFiles(ctx, e, oldFile, newFile, p)
+			if err != nil {
+				return nil, err
+			}
+			result.Status = statusUnchanged
+			if len(diffs) > 0 {
+				result.Status = statusChanged
+			}
+			result.Changes = len(diffs)
+			result.Diffs = diffs
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+//listFiles returns the regular files which names match the pattern, keyed by the slash-separated relative path
+func listFiles(root, pattern string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if ok, _ := path.Match(pattern, d.Name()); !ok {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = p
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on list %s Error : %s", root, err.Error())
+	}
+
+	return files, nil
+}
+
+func isDir(name string) bool {
+	if name == stdinName {
+		return false
+	}
+
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+func differs(results []fileResult) bool {
+	for _, r := range results {
+		if r.Status != statusUnchanged {
+			return true
+		}
+	}
+
+	return false
+}
+
+//writeFiles writes the diffs of the changed files followed by a summary of the files which are different
+func (o *output) writeFiles(ctx context.Context, w io.Writer, results []fileResult) error {
+	diffs := []diff.Diff{}
+	changed := []diff.Diff{}
+	for _, r := range results {
+		diffs = append(diffs, r.Diffs...)
+		if r.Status == statusChanged {
+			changed = append(changed, r.Diffs...)
+		}
+	}
+
+	switch o.format {
+	case "json":
+		encoded, err := codec.EncodeJSONDiffs(diffs)
+		if err != nil {
+			return err
+		}
+
+		return writeJSON(w, struct {
+			Files []fileResult     `json:"files"`
+			Diffs []codec.JSONDiff `json:"diffs"`
+		}{results, encoded})
+	case "jsonpatch":
+		patches := map[string][]codec.JSONPatchOperation{}
+		for _, r := range results {
+			if r.Status != statusUnchanged {
+				patches[r.Path] = codec.EncodeJSONPatch(r.Diffs, o.test)
+			}
+		}
+
+		return writeJSON(w, patches)
+	}
+
+	if !differs(results) {
+		return nil
+	}
+
+	if o.format == "markdown" {
+		if err := (&render.MarkdownRenderer{}).Render(ctx, w, changed); err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			fmt.Fprintln(w)
+		}
+
+		return writeMarkdownSummary(w, results)
+	}
+
+	if err := (&render.TextRenderer{Color: o.color}).Render(ctx, w, changed); err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	return writeTextSummary(w, results)
+}
+
+func writeTextSummary(w io.Writer, results []fileResult) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tFILE\tCHANGES")
+	for _, r := range results {
+		if r.Status != statusUnchanged {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Status, r.Path, changes(r))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w, "\n"+totals(results))
+	return err
+}
+
+func writeMarkdownSummary(w io.Writer, results []fileResult) error {
+	fmt.Fprintln(w, "### Summary")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Status | File | Changes |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+	for _, r := range results {
+		if r.Status != statusUnchanged {
+			fmt.Fprintf(w, "| %s | %s | %s |\n", r.Status, render.MarkdownCodeSpan(r.Path), changes(r))
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "\n"+totals(results))
+	return err
+}
+
+func changes(r fileResult) string {
+	if r.Status != statusChanged {
+		return "-"
+	}
+
+	return fmt.Sprint(r.Changes)
+}
+
+func totals(results []fileResult) string {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	return fmt.Sprintf("%d added, %d removed, %d changed, %d unchanged",
+		counts[statusAdded], counts[statusRemoved], counts[statusChanged], counts[statusUnchanged])
+}
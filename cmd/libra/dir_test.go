@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Directory(t *testing.T) {
+	old, new := t.TempDir(), t.TempDir()
+	for _, dir := range []string{filepath.Join(old, "prod"), filepath.Join(new, "prod")} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("os.Mkdir() error = %v", err)
+		}
+	}
+	writeFile(t, old, "prod/app.json", `{"name": "app", "port": 80}`)
+	writeFile(t, new, "prod/app.json", `{"port": 80, "name": "web"}`)
+	writeFile(t, old, "same.json", `{"a": [1, 2]}`)
+	writeFile(t, new, "same.json", `{ "a": [1, 2.0] }`)
+	writeFile(t, old, "removed.json", `{}`)
+	writeFile(t, new, "added.json", `[]`)
+	writeFile(t, new, "README", `not a JSON document`)
+
+	equal := t.TempDir()
+	writeFile(t, equal, "same.json", `{"a":[1,2]}`)
+
+	escaped := t.TempDir()
+	writeFile(t, escaped, "a|b`.json", `{}`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStdout []string
+		wantStderr string
+	}{
+		{
+			"succeed when write the text",
+			[]string{"diff", old, new},
+			exitDifferent,
+			[]string{
+				"~ prod/app.json\n  ~ name: \"app\" -> \"web\"\n",
+				"STATUS   FILE           CHANGES\nadded    added.json     -\nchanged  prod/app.json  1\nremoved  removed.json   -\n",
+				"1 added, 1 removed, 1 changed, 1 unchanged\n",
+			},
+			"",
+		}, {
+			"succeed when write the Markdown",
+			[]string{"diff", "-format", "markdown", old, new},
+			exitDifferent,
+			[]string{"### prod/app.json", "| added | `added.json` | - |", "| changed | `prod/app.json` | 1 |"},
+			"",
+		}, {
+			"succeed when escape the file paths within the Markdown",
+			[]string{"diff", "-format", "markdown", equal, escaped},
+			exitDifferent,
+			[]string{"| added | `` a\\|b`.json `` | - |"},
+			"",
+		}, {
+			"succeed when write the JSON",
+			[]string{"diff", "-format", "json", old, new},
+			exitDifferent,
+			[]string{`"path": "same.json",` + "\n      \"status\": \"unchanged\"", `"object_type": "prod/app.json",`},
+			"",
+		}, {
+			"succeed when write the JSON Patch",
+			[]string{"diff", "-format", "jsonpatch", old, new},
+			exitDifferent,
+			[]string{`"removed.json": [` + "\n    {\n      \"op\": \"remove\",\n      \"path\": \"\"\n    }"},
+			"",
+		}, {
+			"succeed when the directories are equal",
+			[]string{"diff", old + "/prod", old + "/prod"},
+			exitEqual,
+			nil,
+			"",
+		}, {
+			"succeed when only the included files are compared",
+			[]string{"diff", "-include", "same.*", old, equal},
+			exitEqual,
+			nil,
+			"",
+		}, {
+			"failed when compare a directory with a file",
+			[]string{"diff", old, filepath.Join(new, "same.json")},
+			exitError,
+			nil,
+			"only be compared with another directory",
+		}, {
+			"failed when the include pattern is invalid",
+			[]string{"diff", "-include", "[", old, new},
+			exitError,
+			nil,
+			"invalid include pattern",
+		}, {
+			"failed when a file is invalid",
+			[]string{"diff", "-include", "*", old, new},
+			exitError,
+			nil,
+			"README",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(""), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if len(tt.wantStdout) == 0 && stdout.Len() > 0 {
+				t.Errorf("run() stdout = %s, want empty", stdout)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("run() stdout = %s, want %s", stdout, want)
+				}
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsonl"
+)
+
+func runJSONL(ctx context.Context, e env, args []string) int {
+	fs := flag.NewFlagSet("jsonl", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: libra jsonl [flags] OLD NEW")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Compare two JSON Lines datasets which records are matched by the key, use - to read one of them from the standard input.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	out := &output{}
+	out.register(fs)
+	opts := jsonl.Options{}
+	fs.StringVar(&opts.Key, "key", "id", "dotted path of the record key, e.g. customer.id")
+	memory := fs.Int("memory", 32, "maximum size in MiB of the records which are sorted in memory before they are spilled to disk")
+	fs.StringVar(&opts.TempDir, "tmpdir", "", "directory of the temporary files, the default is the system temporary directory")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	if err := out.validate(); err != nil {
+		fmt.Fprintf(e.stderr, "libra jsonl: %s\n", err.Error())
+		return exitError
+	}
+
+	if fs.Arg(0) == stdinName && fs.Arg(1) == stdinName {
+		fmt.Fprintln(e.stderr, "libra jsonl: only one of the datasets could be read from the standard input")
+		return exitError
+	}
+	opts.BufferSize = *memory << 20
+
+	old, err := openDataset(e, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra jsonl: %s\n", err.Error())
+		return exitError
+	}
+	defer old.Close()
+
+	new, err := openDataset(e, fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra jsonl: %s\n", err.Error())
+		return exitError
+	}
+	defer new.Close()
+
+	objectType := documentName(fs.Arg(1))
+	w := newRecordWriter(out, e.stdout, "records")
+	err = jsonl.Compare(ctx, old, new, opts, func(diffs []diff.Diff) error {
+		for i := range diffs {
+			diffs[i].ObjectType = objectType
+		}
+
+		return w.write(ctx, diffs)
+	})
+	if err == nil {
+		err = w.close()
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra jsonl: %s\n", err.Error())
+		return exitError
+	}
+
+	if w.records() > 0 {
+		return exitDifferent
+	}
+
+	return exitEqual
+}
+
+func openDataset(e env, name string) (io.ReadCloser, error) {
+	if name == stdinName {
+		return io.NopCloser(e.stdin), nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	return f, nil
+}
+
+//recordWriter streams the diffs of the records, so the whole result is never held in memory.
+//The JSON output is an array of the diffs and the JSON Patch output is an object of the operations keyed by the record key
+type recordWriter struct {
+	output   *output
+	w        io.Writer
+	noun     string
+	elements int
+	counts   map[diff.ChangeType]int
+}
+
+func newRecordWriter(out *output, w io.Writer, noun string) *recordWriter {
+	return &recordWriter{output: out, w: w, noun: noun, counts: map[diff.ChangeType]int{}}
+}
+
+func (r *recordWriter) records() int {
+	return r.counts[diff.New] + r.counts[diff.Removed] + r.counts[diff.Changed]
+}
+
+func (r *recordWriter) write(ctx context.Context, diffs []diff.Diff) error {
+	r.counts[recordChangeType(diffs)]++
+
+	switch r.output.format {
+	case "json":
+		for _, d := range diffs {
+			encoded, err := codec.EncodeJSONDiff(d)
+			if err != nil {
+				return err
+			}
+
+			if err := r.element("", encoded); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case "jsonpatch":
+		key, err := json.Marshal(diffs[0].ObjectID)
+		if err != nil {
+			return err
+		}
+
+		return r.element(string(key)+": ", codec.EncodeJSONPatch(diffs, r.output.test))
+	case "markdown":
+		if r.records() > 1 {
+			fmt.Fprintln(r.w)
+		}
+	}
+
+	return r.output.write(ctx, r.w, diffs)
+}
+
+//element writes an element of the JSON array or object, which is opened before the first element
+func (r *recordWriter) element(prefix string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if r.elements == 0 {
+		separator = r.brackets()[:1] + "\n  "
+	}
+	r.elements++
+
+	_, err = fmt.Fprintf(r.w, "%s%s%s", separator, prefix, b)
+	return err
+}
+
+func (r *recordWriter) brackets() string {
+	if r.output.format == "jsonpatch" {
+		return "{}"
+	}
+
+	return "[]"
+}
+
+//close closes the JSON output, or writes the number of the different records
+func (r *recordWriter) close() error {
+	switch r.output.format {
+	case "json", "jsonpatch":
+		if r.elements == 0 {
+			_, err := fmt.Fprintln(r.w, r.brackets())
+			return err
+		}
+
+		_, err := fmt.Fprintf(r.w, "\n%s\n", r.brackets()[1:])
+		return err
+	}
+
+	if r.records() == 0 {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(r.w, "\n%d added, %d removed, %d changed %s\n", r.counts[diff.New], r.counts[diff.Removed], r.counts[diff.Changed], r.noun)
+	return err
+}
+
+//recordChangeType returns the change type of a record, which is Changed unless the whole record is added or removed
+func recordChangeType(diffs []diff.Diff) diff.ChangeType {
+	if len(diffs) == 1 && diffs[0].Field == "" && diffs[0].ChangeType != diff.Changed {
+		return diffs[0].ChangeType
+	}
+
+	return diff.Changed
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_JSONL(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.jsonl", "{\"id\": 1, \"name\": \"a\"}\n{\"id\": 2, \"name\": \"b\"}\n")
+	new := writeFile(t, dir, "new.jsonl", "{\"id\": 3, \"name\": \"c\"}\n{\"name\": \"B\", \"id\": 2}\n")
+	nested := writeFile(t, dir, "nested.jsonl", "{\"customer\": {\"id\": 1}}\n")
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"succeed when write the text",
+			[]string{"jsonl", old, new},
+			"",
+			exitDifferent,
+			"- new.jsonl#1: map[id:1 name:a]\n~ new.jsonl#2\n  ~ name: \"b\" -> \"B\"\n+ new.jsonl#3: map[id:3 name:c]\n\n1 added, 1 removed, 1 changed records\n",
+			"",
+		}, {
+			"succeed when write the JSON diffs",
+			[]string{"jsonl", "-format", "json", old, new},
+			"",
+			exitDifferent,
+			"[\n  {\n    \"change_type\": \"removed\",\n    \"object_type\": \"new.jsonl\",\n    \"object_id\": \"1\",\n    \"old\": {\n      \"id\": 1,\n      \"name\": \"a\"\n    }\n  },\n" +
+				"  {\n    \"change_type\": \"changed\",\n    \"object_type\": \"new.jsonl\",\n    \"object_id\": \"2\",\n    \"field\": \"name\",\n    \"old\": \"b\",\n    \"new\": \"B\"\n  },\n" +
+				"  {\n    \"change_type\": \"new\",\n    \"object_type\": \"new.jsonl\",\n    \"object_id\": \"3\",\n    \"new\": {\n      \"id\": 3,\n      \"name\": \"c\"\n    }\n  }\n]\n",
+			"",
+		}, {
+			"succeed when write the JSON Patch",
+			[]string{"jsonl", "-format", "jsonpatch", old, new},
+			"",
+			exitDifferent,
+			"{\n  \"1\": [\n    {\n      \"op\": \"remove\",\n      \"path\": \"\"\n    }\n  ],\n  \"2\": [\n    {\n      \"op\": \"replace\",\n      \"path\": \"/name\",\n      \"value\": \"B\"\n    }\n  ],\n" +
+				"  \"3\": [\n    {\n      \"op\": \"add\",\n      \"path\": \"\",\n      \"value\": {\n        \"id\": 3,\n        \"name\": \"c\"\n      }\n    }\n  ]\n}\n",
+			"",
+		}, {
+			"succeed when the keys have different types",
+			[]string{"jsonl", "-format", "jsonpatch", old, "-"},
+			"{\"id\": \"1\", \"name\": \"x\"}\n{\"id\": 1, \"name\": \"A\"}\n{\"id\": 2, \"name\": \"b\"}\n",
+			exitDifferent,
+			"{\n  \"\\\"1\\\"\": [\n    {\n      \"op\": \"add\",\n      \"path\": \"\",\n      \"value\": {\n        \"id\": \"1\",\n        \"name\": \"x\"\n      }\n    }\n  ],\n" +
+				"  \"1\": [\n    {\n      \"op\": \"replace\",\n      \"path\": \"/name\",\n      \"value\": \"A\"\n    }\n  ]\n}\n",
+			"",
+		}, {
+			"succeed when the datasets are equal",
+			[]string{"jsonl", "-format", "json", "-memory", "0", old, "-"},
+			"{\"id\": 2, \"name\": \"b\"}\n{\"id\": 1, \"name\": \"a\"}\n",
+			exitEqual,
+			"[]\n",
+			"",
+		}, {
+			"succeed when match the records by the nested key",
+			[]string{"jsonl", "-key", "customer.id", nested, nested},
+			"",
+			exitEqual,
+			"",
+			"",
+		}, {
+			"failed when the key is not found",
+			[]string{"jsonl", old, nested},
+			"",
+			exitError,
+			"",
+			"line 1: key id is not found",
+		}, {
+			"failed when the dataset is not found",
+			[]string{"jsonl", old, dir + "/missing.jsonl"},
+			"",
+			exitError,
+			"",
+			"error on read",
+		}, {
+			"failed when the arguments are missing",
+			[]string{"jsonl", old},
+			"",
+			exitError,
+			"",
+			"Usage: libra jsonl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(tt.stdin), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/lint"
+)
+
+func runLint(ctx context.Context, e env, args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: libra lint [DIR...]")
+		fmt.Fprintln(flags.Output())
+		fmt.Fprintln(flags.Output(), "Check the libra struct tags of the Go packages in the directories, DIR/... checks the subdirectories too.")
+		fmt.Fprintln(flags.Output(), "The invalid tag values, duplicate IDs, tags on unexported fields and ID fields of unsupported kinds are reported.")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	dirs := []string{}
+	for _, pattern := range patterns {
+		expanded, err := expandDir(pattern)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "libra lint: %s\n", err.Error())
+			return exitError
+		}
+		dirs = append(dirs, expanded...)
+	}
+
+	found := false
+	for _, dir := range dirs {
+		issues, err := lint.Dir(ctx, dir)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "libra lint: %s\n", err.Error())
+			return exitError
+		}
+
+		for _, issue := range issues {
+			fmt.Fprintln(e.stdout, issue)
+		}
+		found = found || len(issues) > 0
+	}
+
+	if found {
+		return exitDifferent
+	}
+
+	return exitEqual
+}
+
+//expandDir returns the directory, or the directory and its subdirectories when it ends with /...
+//The hidden, testdata and vendor directories are skipped the same way as the go command
+func expandDir(pattern string) ([]string, error) {
+	root, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+	if root == "" {
+		root = "."
+	}
+	root = filepath.FromSlash(root)
+
+	if !recursive {
+		if !isDir(root) {
+			return nil, fmt.Errorf("%s is not a directory", pattern)
+		}
+
+		return []string{root}, nil
+	}
+
+	dirs := []string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on walk %s Error : %s", root, err.Error())
+	}
+
+	return dirs, nil
+}
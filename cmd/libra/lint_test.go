@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Lint(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "order.go", "package a\n\ntype Order struct {\n\tID string `libra:\"id\"`\n}\n")
+	if err := os.MkdirAll(filepath.Join(dir, "sub", "testdata"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "sub"), "item.go", "package sub\n\ntype Item struct {\n\tNote string `libra:\"igonre\"`\n}\n")
+	writeFile(t, filepath.Join(dir, "sub", "testdata"), "bad.go", "package bad\n\ntype Bad struct {\n\tnote string `libra:\"x\"`\n}\n")
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"succeed when the tags are valid",
+			[]string{"lint", dir},
+			exitEqual,
+			"",
+			"",
+		}, {
+			"succeed when check the subdirectories",
+			[]string{"lint", dir + "/..."},
+			exitDifferent,
+			filepath.Join(dir, "sub", "item.go") + ":4:14: invalid libra tag value \"igonre\", expected id or ignore\n",
+			"",
+		}, {
+			"failed when the directory is not found",
+			[]string{"lint", filepath.Join(dir, "missing")},
+			exitError,
+			"",
+			"is not a directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(""), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+//Command libra compares JSON documents, JSON Lines datasets, CSV tables and XML documents, and applies the differences from the command line or over HTTP. It also checks the libra struct tags of Go packages.
+//
+//Usage:
+//
+//	libra diff [flags] OLD NEW
+//	libra diff [flags] OLDDIR NEWDIR
+//	libra jsonl [flags] -key id OLD NEW
+//	libra csv [flags] -key id OLD NEW
+//	libra xml [flags] OLD NEW
+//	libra apply [flags] DOCUMENT PATCH
+//	libra serve [flags]
+//	libra lint [DIR...]
+//
+//The exit codes follow diff(1), it is 0 when the documents are equal, 1 when they are different and 2 on error.
+//The apply command exits with 1 when the patch is conflicted with the document, and the lint command when an issue is found
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitEqual     = 0
+	exitDifferent = 1
+	exitError     = 2
+)
+
+//env is the standard streams of a command
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, e env, args []string) int
+}
+
+var commands = []command{
+	{"diff", "compare two JSON documents", runDiff},
+	{"jsonl", "compare two JSON Lines datasets by a key", runJSONL},
+	{"csv", "compare two CSV files as tables by the key columns", runCSV},
+	{"xml", "compare two XML documents", runXML},
+	{"apply", "apply the JSON diffs or JSON Patch onto a JSON document", runApply},
+	{"serve", "serve the diff and patch of JSON documents over HTTP", runServe},
+	{"lint", "check the libra struct tags of Go packages", runLint},
+}
+
+func main() {
+	os.Exit(run(context.Background(), env{os.Stdin, os.Stdout, os.Stderr}, os.Args[1:]))
+}
+
+func run(ctx context.Context, e env, args []string) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitError
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(e.stdout)
+		return exitEqual
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(ctx, e, args[1:])
+		}
+	}
+
+	fmt.Fprintf(e.stderr, "libra: unknown command %q\n", args[0])
+	usage(e.stderr)
+	return exitError
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: libra <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.description)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'libra <command> -h' for the flags of a command")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.json", `{"name": "app", "port": 80, "tags": ["a", "b"]}`)
+	new := writeFile(t, dir, "new.json", `{"port": 80, "name": "web", "tags": ["a"], "tls": true}`)
+	invalid := writeFile(t, dir, "invalid.json", `{"name": `)
+	large := writeFile(t, dir, "large.json", `{"id": 9007199254740993}`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"succeed when the documents are equal",
+			[]string{"diff", old, old},
+			"",
+			exitEqual,
+			"",
+			"",
+		}, {
+			"succeed when write the text",
+			[]string{"diff", old, new},
+			"",
+			exitDifferent,
+			"~ new.json\n  ~ name: \"app\" -> \"web\"\n  ~ tags\n    - 1: \"b\"\n  + tls: true\n",
+			"",
+		}, {
+			"succeed when write the JSON diffs",
+			[]string{"diff", "-format", "json", old, new},
+			"",
+			exitDifferent,
+			`"field": "tags.1",`,
+			"",
+		}, {
+			"succeed when write the JSON Patch",
+			[]string{"diff", "-format", "jsonpatch", "-test", old, new},
+			"",
+			exitDifferent,
+			`"op": "test",`,
+			"",
+		}, {
+			"succeed when write the Markdown",
+			[]string{"diff", "-format", "markdown", old, new},
+			"",
+			exitDifferent,
+			"| ~ | `name` | `\"app\"` | `\"web\"` |",
+			"",
+		}, {
+			"succeed when read the document from stdin",
+			[]string{"diff", "-", new},
+			`{"name": "web", "port": 80, "tags": ["a"], "tls": true}`,
+			exitEqual,
+			"",
+			"",
+		}, {
+			"succeed when the large integers are different",
+			[]string{"diff", "-", large},
+			`{"id": 9007199254740992}`,
+			exitDifferent,
+			"~ large.json\n  ~ id: 9007199254740992 -> 9007199254740993\n",
+			"",
+		}, {
+			"succeed when print the usage",
+			[]string{"help"},
+			"",
+			exitEqual,
+			"Usage: libra",
+			"",
+		}, {
+			"failed when the command is missing",
+			[]string{},
+			"",
+			exitError,
+			"",
+			"Usage: libra",
+		}, {
+			"failed when the command is unknown",
+			[]string{"merge"},
+			"",
+			exitError,
+			"",
+			`unknown command "merge"`,
+		}, {
+			"failed when the arguments are missing",
+			[]string{"diff", old},
+			"",
+			exitError,
+			"",
+			"Usage: libra diff",
+		}, {
+			"failed when the format is unsupported",
+			[]string{"diff", "-format", "yaml", old, new},
+			"",
+			exitError,
+			"",
+			`unsupported format "yaml"`,
+		}, {
+			"failed when both documents are read from stdin",
+			[]string{"diff", "-", "-"},
+			"{}",
+			exitError,
+			"",
+			"standard input",
+		}, {
+			"failed when the document is invalid",
+			[]string{"diff", old, invalid},
+			"",
+			exitError,
+			"",
+			"error on read " + invalid,
+		}, {
+			"failed when the document is not found",
+			[]string{"diff", old, filepath.Join(dir, "missing.json")},
+			"",
+			exitError,
+			"",
+			"error on read",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(tt.stdin), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if tt.wantStdout == "" && stdout.Len() > 0 || !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("run() stdout = %s, want %s", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/server"
+)
+
+//shutdownTimeout is the time which the in-flight requests are given when the server is stopped
+const shutdownTimeout = 10 * time.Second
+
+func runServe(ctx context.Context, e env, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: libra serve [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Serve POST /diff, POST /patch and GET /healthz over HTTP until it is interrupted.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	addr := fs.String("addr", ":8080", "address to listen on")
+	maxBodySize := fs.Int64("max-body", server.DefaultMaxBodySize, "maximum size in bytes of a request body")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra serve: %s\n", err.Error())
+		return exitError
+	}
+
+	srv := &http.Server{
+		Handler:           server.New(server.Options{MaxBodySize: *maxBodySize}),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+	fmt.Fprintf(e.stderr, "libra serve: listening on %s\n", ln.Addr())
+
+	select {
+	case err := <-errs:
+		fmt.Fprintf(e.stderr, "libra serve: %s\n", err.Error())
+		return exitError
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(e.stderr, "libra serve: %s\n", err.Error())
+		return exitError
+	}
+
+	return exitEqual
+}
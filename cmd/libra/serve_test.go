@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//syncBuffer is a bytes.Buffer which could be read while the server writes into it
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.b.String()
+}
+
+func TestRun_Serve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stderr := &syncBuffer{}
+	done := make(chan int, 1)
+	go func() {
+		done <- run(ctx, env{strings.NewReader(""), &bytes.Buffer{}, stderr}, []string{"serve", "-addr", "127.0.0.1:0"})
+	}()
+
+	addr := ""
+	for deadline := time.Now().Add(5 * time.Second); addr == "" && time.Now().Before(deadline); {
+		if _, after, ok := strings.Cut(stderr.String(), "listening on "); ok {
+			addr = strings.TrimSpace(after)
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if addr == "" {
+		t.Fatalf("run() stderr = %s, want the listening address", stderr)
+	}
+
+	resp, err := http.Post("http://"+addr+"/diff", "application/json", strings.NewReader(`{"old": {"a": 1}, "new": {"a": 2}}`))
+	if err != nil {
+		t.Fatalf("http.Post() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("http.Post() status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	cancel()
+	select {
+	case got := <-done:
+		if got != exitEqual {
+			t.Errorf("run() = %v, want %v, stderr %s", got, exitEqual, stderr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run() is not stopped")
+	}
+}
+
+func TestRun_ServeInvalidAddress(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	if got := run(context.Background(), env{strings.NewReader(""), &bytes.Buffer{}, stderr}, []string{"serve", "-addr", "invalid:address:1"}); got != exitError {
+		t.Errorf("run() = %v, want %v, stderr %s", got, exitError, stderr)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/haritsfahreza/libra/pkg/xmltree"
+)
+
+func runXML(ctx context.Context, e env, args []string) int {
+	fs := flag.NewFlagSet("xml", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: libra xml [flags] OLD NEW")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Compare two XML documents, use - to read one of them from the standard input.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+
+	out := &output{}
+	out.register(fs)
+	id := fs.String("id", "", "attribute which identifies the child elements, e.g. id. The elements are matched by their position when it is empty")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitEqual
+		}
+		return exitError
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	if err := out.validate(); err != nil {
+		fmt.Fprintf(e.stderr, "libra xml: %s\n", err.Error())
+		return exitError
+	}
+
+	if out.format == "jsonpatch" {
+		fmt.Fprintln(e.stderr, "libra xml: jsonpatch format is not supported for the XML documents")
+		return exitError
+	}
+
+	if fs.Arg(0) == stdinName && fs.Arg(1) == stdinName {
+		fmt.Fprintln(e.stderr, "libra xml: only one of the documents could be read from the standard input")
+		return exitError
+	}
+
+	old, err := readXML(e, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra xml: %s\n", err.Error())
+		return exitError
+	}
+
+	new, err := readXML(e, fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra xml: %s\n", err.Error())
+		return exitError
+	}
+
+	diffs, err := xmltree.Compare(ctx, old, new, xmltree.Options{IDAttribute: *id})
+	if err != nil {
+		fmt.Fprintf(e.stderr, "libra xml: %s\n", err.Error())
+		return exitError
+	}
+
+	objectType := documentName(fs.Arg(1))
+	for i := range diffs {
+		diffs[i].ObjectType = objectType
+	}
+
+	if err := out.write(ctx, e.stdout, diffs); err != nil {
+		fmt.Fprintf(e.stderr, "libra xml: %s\n", err.Error())
+		return exitError
+	}
+
+	if len(diffs) > 0 {
+		return exitDifferent
+	}
+
+	return exitEqual
+}
+
+func readXML(e env, name string) (*xmltree.Element, error) {
+	if name == stdinName {
+		root, err := xmltree.Parse(e.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error on read stdin Error : %s", err.Error())
+		}
+
+		return root, nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+	defer f.Close()
+
+	root, err := xmltree.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("error on read %s Error : %s", name, err.Error())
+	}
+
+	return root, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_XML(t *testing.T) {
+	dir := t.TempDir()
+	old := writeFile(t, dir, "old.xml", `<order status="open"><item id="a1" qty="1">Pen</item><item id="b2" qty="2">Ink</item></order>`)
+	new := writeFile(t, dir, "new.xml", `<order status="paid"><item id="b2" qty="3">Ink</item></order>`)
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"succeed when write the text",
+			[]string{"xml", "-id", "id", old, new},
+			"",
+			exitDifferent,
+			"~ new.xml\n  ~ /order/@status: \"open\" -> \"paid\"\n  - /order/item[@id='a1']: \"<item id=\\\"a1\\\" qty=\\\"1\\\">Pen</item>\"\n  ~ /order/item[@id='b2']/@qty: \"2\" -> \"3\"\n",
+			"",
+		}, {
+			"succeed when write the json",
+			[]string{"xml", "-format", "json", old, "-"},
+			`<order status="paid"><item qty="1" id="a1">Pen</item><item id="b2" qty="2">Ink</item></order>`,
+			exitDifferent,
+			"[\n  {\n    \"change_type\": \"changed\",\n    \"object_type\": \"stdin\",\n    \"object_id\": \"\",\n    \"field\": \"/order/@status\",\n    \"old\": \"open\",\n    \"new\": \"paid\"\n  }\n]\n",
+			"",
+		}, {
+			"succeed when the ID contains dots",
+			[]string{"xml", "-id", "id", old, "-"},
+			`<order status="open"><item id="a1" qty="1">Pen</item><item id="b2" qty="2">Ink</item><item id="c.3" qty="1">Pad</item></order>`,
+			exitDifferent,
+			"~ stdin\n  + /order/item[@id='c.3']: \"<item id=\\\"c.3\\\" qty=\\\"1\\\">Pad</item>\"\n",
+			"",
+		}, {
+			"succeed when the documents are equal",
+			[]string{"xml", old, "-"},
+			"<order status=\"open\">\n  <item qty=\"1\" id=\"a1\">Pen</item>\n  <item id=\"b2\" qty=\"2\">Ink</item>\n</order>\n",
+			exitEqual,
+			"",
+			"",
+		}, {
+			"failed when the format is jsonpatch",
+			[]string{"xml", "-format", "jsonpatch", old, new},
+			"",
+			exitError,
+			"",
+			"jsonpatch format is not supported",
+		}, {
+			"failed when the IDs are duplicated",
+			[]string{"xml", "-id", "id", old, "-"},
+			`<order status="open"><item id="a1">Pen</item><item id="a1">Ink</item></order>`,
+			exitError,
+			"",
+			"duplicate ID on /order/item[@id='a1']",
+		}, {
+			"failed when the document is invalid",
+			[]string{"xml", old, "-"},
+			"<order>",
+			exitError,
+			"",
+			"error on read stdin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			got := run(context.Background(), env{strings.NewReader(tt.stdin), stdout, stderr}, tt.args)
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v, stderr %s", got, tt.want, stderr)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("run() stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if tt.wantStderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %s, want %s", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
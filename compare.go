@@ -29,3 +29,13 @@ func Compare(ctx context.Context, old, new interface{}) ([]diff.Diff, error) {
 
 	return comparator.GetComparator(oldVal.Kind()).Compare(ctx, oldVal, newVal)
 }
+
+//CompareTree is used to compare two different values and return the differences as a tree
+func CompareTree(ctx context.Context, old, new interface{}) (*diff.DiffNode, error) {
+	diffs, err := Compare(ctx, old, new)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff.NewTree(diffs), nil
+}
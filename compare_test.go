@@ -130,6 +130,32 @@ func TestCompare(t *testing.T) {
 	}
 }
 
+func TestCompareTree(t *testing.T) {
+	oldPerson := person{ID: 1, Name: "test1", Age: 22}
+	newPerson := person{ID: 1, Name: "test2", Age: 22}
+
+	got, err := libra.CompareTree(context.Background(), oldPerson, newPerson)
+	if err != nil {
+		t.Fatalf("CompareTree() error = %v", err)
+	}
+
+	want := []diff.Diff{{
+		ChangeType: diff.Changed,
+		ObjectType: "libra_test.person",
+		ObjectID:   "1",
+		Field:      "Name",
+		Old:        "test1",
+		New:        "test2",
+	}}
+	if len(got.Children) != 1 || !reflect.DeepEqual(got.Flatten(), want) {
+		t.Errorf("CompareTree() = %v, want %v", got.Flatten(), want)
+	}
+
+	if _, err := libra.CompareTree(context.Background(), nil, nil); err == nil {
+		t.Errorf("CompareTree() error = nil, wantErr true")
+	}
+}
+
 var bDiffs []diff.Diff
 
 func benchmarkCompare(old, new interface{}, b *testing.B) {
@@ -0,0 +1,59 @@
+package libra
+
+import "context"
+
+type contextKey int
+
+const (
+	authorKey contextKey = iota
+	commitPropertiesKey
+)
+
+//WithAuthor returns a copy of the context which carries the author of the commits
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return context.WithValue(ctx, authorKey, author)
+}
+
+//AuthorFromContext returns the author which is carried by the context
+func AuthorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	author, _ := ctx.Value(authorKey).(string)
+	return author
+}
+
+//WithCommitProperties returns a copy of the context which carries the properties of the commits, e.g. the correlation ID.
+//The properties are merged with the ones which are already carried by the context
+func WithCommitProperties(ctx context.Context, properties map[string]string) context.Context {
+	merged := map[string]string{}
+	for k, v := range CommitPropertiesFromContext(ctx) {
+		merged[k] = v
+	}
+
+	for k, v := range properties {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, commitPropertiesKey, merged)
+}
+
+//CommitPropertiesFromContext returns a copy of the properties which are carried by the context
+func CommitPropertiesFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+
+	properties, ok := ctx.Value(commitPropertiesKey).(map[string]string)
+	if !ok {
+		return nil
+	}
+
+	copied := make(map[string]string, len(properties))
+	for k, v := range properties {
+		copied[k] = v
+	}
+
+	return copied
+}
@@ -0,0 +1,58 @@
+package libra_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra"
+)
+
+func TestWithAuthor(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"return the author", libra.WithAuthor(context.Background(), "alice"), "alice"},
+		{"return the latest author", libra.WithAuthor(libra.WithAuthor(context.Background(), "alice"), "bob"), "bob"},
+		{"return empty without author", context.Background(), ""},
+		{"return empty on nil context", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := libra.AuthorFromContext(tt.ctx); got != tt.want {
+				t.Errorf("AuthorFromContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCommitProperties(t *testing.T) {
+	ctx := libra.WithCommitProperties(context.Background(), map[string]string{"request_id": "1", "source": "api"})
+	ctx = libra.WithCommitProperties(ctx, map[string]string{"request_id": "2"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]string
+	}{
+		{"return the merged properties", ctx, map[string]string{"request_id": "2", "source": "api"}},
+		{"return nil without properties", context.Background(), nil},
+		{"return nil on nil context", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := libra.CommitPropertiesFromContext(tt.ctx)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CommitPropertiesFromContext() = %v, want %v", got, tt.want)
+			}
+			if got != nil {
+				got["mutated"] = "true"
+				if _, ok := libra.CommitPropertiesFromContext(tt.ctx)["mutated"]; ok {
+					t.Errorf("CommitPropertiesFromContext() returns the shared map")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package libra
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+//MergeConflict represents a path which is changed differently by both sides of a merge.
+//Ours and Theirs contain the diffs of each side on the path, including the diffs of its nested fields
+type MergeConflict struct {
+	ObjectType string
+	ObjectID   string
+	Field      string
+	Base       interface{}
+	Ours       []diff.Diff
+	Theirs     []diff.Diff
+}
+
+//Resolve returns the diffs which replace the base value of the conflicted path with the given value
+func (c MergeConflict) Resolve(value interface{}) []diff.Diff {
+	return []diff.Diff{{
+		ChangeType: diff.Changed,
+		ObjectType: c.ObjectType,
+		ObjectID:   c.ObjectID,
+		Field:      c.Field,
+		Old:        c.Base,
+		New:        value,
+	}}
+}
+
+//Resolver decides the diffs which are applied for a conflict, it returns false when the conflict is left unresolved
+type Resolver func(ctx context.Context, conflict MergeConflict) ([]diff.Diff, bool)
+
+//OursResolver resolves the conflicts by taking our changes
+func OursResolver(ctx context.Context, conflict MergeConflict) ([]diff.Diff, bool) {
+	return conflict.Ours, true
+}
+
+//TheirsResolver resolves the conflicts by taking their changes
+func TheirsResolver(ctx context.Context, conflict MergeConflict) ([]diff.Diff, bool) {
+	return conflict.Theirs, true
+}
+
+//PathResolver applies the resolver only on the conflicts which field matches the pattern segment by segment, e.g. `Address.*`
+func PathResolver(pattern string, resolver Resolver) Resolver {
+	return func(ctx context.Context, conflict MergeConflict) ([]diff.Diff, bool) {
+		matched, err := diff.MatchField(pattern, conflict.Field)
+		if err != nil || !matched {
+			return nil, false
+		}
+
+		return resolver(ctx, conflict)
+	}
+}
+
+//Merge is used to merge two different changes of the base value.
+//The changes which do not overlap are applied onto a copy of the base value, the overlapped ones are passed to the resolvers in order.
+//It returns the merged value along with the conflicts which are left unresolved
+func Merge(ctx context.Context, base, ours, theirs interface{}, resolvers ...Resolver) (interface{}, []MergeConflict, error) {
+	if base == nil {
+		return nil, nil, fmt.Errorf("base cannot be nil")
+	}
+
+	oursDiffs, err := Compare(ctx, base, ours)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error on compare ours Error : %s", err.Error())
+	}
+
+	theirsDiffs, err := Compare(ctx, base, theirs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error on compare theirs Error : %s", err.Error())
+	}
+
+	baseVal := reflect.ValueOf(base)
+	merged := reflect.New(baseVal.Type()).Elem()
+	merged.Set(patcher.Clone(baseVal))
+
+	diffs, conflicts, err := mergeDiffs(ctx, merged, oursDiffs, theirsDiffs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unresolved := []MergeConflict{}
+	for _, conflict := range conflicts {
+		resolved := false
+		for _, resolver := range resolvers {
+			var resolvedDiffs []diff.Diff
+			if resolvedDiffs, resolved = resolver(ctx, conflict); resolved {
+				diffs = append(diffs, resolvedDiffs...)
+				break
+			}
+		}
+
+		if !resolved {
+			unresolved = append(unresolved, conflict)
+		}
+	}
+
+	if err := patcher.Apply(ctx, merged, diffs); err != nil {
+		return nil, nil, fmt.Errorf("error on apply merged diffs Error : %s", err.Error())
+	}
+
+	return merged.Interface(), unresolved, nil
+}
+
+//mergeDiffs groups the overlapped diffs of both sides into the conflicts, the identical changes are not conflicted
+func mergeDiffs(ctx context.Context, base reflect.Value, ours, theirs []diff.Diff) ([]diff.Diff, []MergeConflict, error) {
+	all := append(append([]diff.Diff{}, ours...), theirs...)
+	groups := make([]int, len(all))
+	for i := range groups {
+		groups[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if groups[i] != i {
+			groups[i] = find(groups[i])
+		}
+		return groups[i]
+	}
+
+	for i := range ours {
+		for j := range theirs {
+			if overlap(ours[i].Field, theirs[j].Field) {
+				groups[find(len(ours)+j)] = find(i)
+			}
+		}
+	}
+
+	members := map[int][]int{}
+	order := []int{}
+	for i := range all {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	diffs := []diff.Diff{}
+	conflicts := []MergeConflict{}
+	for _, root := range order {
+		conflict := MergeConflict{Field: all[root].Field}
+		for _, i := range members[root] {
+			if i < len(ours) {
+				conflict.Ours = append(conflict.Ours, all[i])
+			} else {
+				conflict.Theirs = append(conflict.Theirs, all[i])
+			}
+
+			if len(all[i].Field) < len(conflict.Field) {
+				conflict.Field = all[i].Field
+			}
+		}
+
+		switch {
+		case len(conflict.Theirs) == 0:
+			diffs = append(diffs, conflict.Ours...)
+			continue
+		case len(conflict.Ours) == 0:
+			diffs = append(diffs, conflict.Theirs...)
+			continue
+		case len(conflict.Ours) == 1 && len(conflict.Theirs) == 1 && sameChange(conflict.Ours[0], conflict.Theirs[0]):
+			diffs = append(diffs, conflict.Ours[0])
+			continue
+		}
+
+		current, err := patcher.Lookup(ctx, base, patcher.SplitField(conflict.Field))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error on lookup field %s Error : %s", conflict.Field, err.Error())
+		}
+
+		if current.IsValid() && current.CanInterface() {
+			conflict.Base = current.Interface()
+		}
+		conflict.ObjectType = conflict.Ours[0].ObjectType
+		conflict.ObjectID = conflict.Ours[0].ObjectID
+		conflicts = append(conflicts, conflict)
+	}
+
+	return diffs, conflicts, nil
+}
+
+//overlap reports whether one of the fields is equal to or nested inside of the other one
+func overlap(a, b string) bool {
+	if a == "" || b == "" || a == b {
+		return true
+	}
+
+	return strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+func sameChange(a, b diff.Diff) bool {
+	return a.Field == b.Field && a.ChangeType == b.ChangeType && reflect.DeepEqual(a.New, b.New)
+}
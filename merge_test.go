@@ -0,0 +1,220 @@
+package libra_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+type config struct {
+	ID      int `libra:"id"`
+	Name    string
+	Timeout int
+	Tags    []string
+	Server  server
+	Labels  map[string]string
+}
+
+type server struct {
+	Host string
+	Port int
+}
+
+func TestMerge(t *testing.T) {
+	base := config{
+		ID:      1,
+		Name:    "app",
+		Timeout: 10,
+		Tags:    []string{"a"},
+		Server:  server{Host: "localhost", Port: 80},
+		Labels:  map[string]string{"env": "dev", "team": "core"},
+	}
+
+	type args struct {
+		ours      interface{}
+		theirs    interface{}
+		resolvers []libra.Resolver
+	}
+	tests := []struct {
+		name          string
+		args          args
+		want          interface{}
+		wantConflicts []string
+		wantErr       bool
+	}{
+		{
+			"succeed when both sides change different fields",
+			args{
+				ours:   config{ID: 1, Name: "app2", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs: config{ID: 1, Name: "app", Timeout: 20, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 8080}, Labels: map[string]string{"env": "prod", "team": "core"}},
+			},
+			config{ID: 1, Name: "app2", Timeout: 20, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 8080}, Labels: map[string]string{"env": "prod", "team": "core"}},
+			[]string{},
+			false,
+		}, {
+			"succeed when both sides make the same change",
+			args{
+				ours:   config{ID: 1, Name: "app", Timeout: 30, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs: config{ID: 1, Name: "app", Timeout: 30, Tags: []string{"a", "b"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+			},
+			config{ID: 1, Name: "app", Timeout: 30, Tags: []string{"a", "b"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+			[]string{},
+			false,
+		}, {
+			"return the conflicts without resolvers",
+			args{
+				ours:   config{ID: 1, Name: "ours", Timeout: 20, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs: config{ID: 1, Name: "theirs", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "ops"}},
+			},
+			config{ID: 1, Name: "app", Timeout: 20, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "ops"}},
+			[]string{"Name"},
+			false,
+		}, {
+			"succeed when resolve the conflicts with ours",
+			args{
+				ours:      config{ID: 1, Name: "ours", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs:    config{ID: 1, Name: "theirs", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "remote", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				resolvers: []libra.Resolver{libra.OursResolver},
+			},
+			config{ID: 1, Name: "ours", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "remote", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+			[]string{},
+			false,
+		}, {
+			"succeed when resolve the conflicts with theirs",
+			args{
+				ours:      config{ID: 1, Name: "ours", Timeout: 10, Tags: []string{"a", "b"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs:    config{ID: 1, Name: "theirs", Timeout: 10, Tags: []string{"c"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				resolvers: []libra.Resolver{libra.TheirsResolver},
+			},
+			config{ID: 1, Name: "theirs", Timeout: 10, Tags: []string{"c"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+			[]string{},
+			false,
+		}, {
+			"succeed when resolve the conflicts per path",
+			args{
+				ours:   config{ID: 1, Name: "ours", Timeout: 20, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 81}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs: config{ID: 1, Name: "theirs", Timeout: 30, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 82}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				resolvers: []libra.Resolver{
+					libra.PathResolver("Server.*", libra.TheirsResolver),
+					libra.PathResolver("Timeout", func(ctx context.Context, conflict libra.MergeConflict) ([]diff.Diff, bool) {
+						return conflict.Resolve(conflict.Ours[0].New.(int) + conflict.Theirs[0].New.(int)), true
+					}),
+				},
+			},
+			config{ID: 1, Name: "app", Timeout: 50, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 82}, Labels: map[string]string{"env": "dev", "team": "core"}},
+			[]string{"Name"},
+			false,
+		}, {
+			"succeed when merge the struct pointers",
+			args{
+				ours:   &config{ID: 1, Name: "ours", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs: &config{ID: 1, Name: "app", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "prod", "team": "core"}},
+			},
+			&config{ID: 1, Name: "ours", Timeout: 10, Tags: []string{"a"}, Server: server{Host: "localhost", Port: 80}, Labels: map[string]string{"env": "prod", "team": "core"}},
+			[]string{},
+			false,
+		}, {
+			"failed when the types are different",
+			args{
+				ours:   config{ID: 1, Labels: map[string]string{"env": "dev", "team": "core"}},
+				theirs: person{ID: 1},
+			},
+			nil,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := interface{}(base)
+			if reflect.TypeOf(tt.args.ours).Kind() == reflect.Ptr {
+				copied := base
+				copied.Labels = map[string]string{"env": "dev", "team": "core"}
+				b = &copied
+			}
+
+			got, conflicts, err := libra.Merge(context.Background(), b, tt.args.ours, tt.args.theirs, tt.args.resolvers...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Merge() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() got = %+v, want %+v", got, tt.want)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			fields := []string{}
+			for _, c := range conflicts {
+				fields = append(fields, c.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantConflicts) {
+				t.Errorf("Merge() conflicts = %v, want %v", fields, tt.wantConflicts)
+			}
+		})
+	}
+
+	if base.Labels["env"] != "dev" || base.Tags[0] != "a" {
+		t.Errorf("Merge() modifies the base value %+v", base)
+	}
+}
+
+func TestMerge_NestedConflict(t *testing.T) {
+	type node struct {
+		ID     int `libra:"id"`
+		Server server
+		Owner  map[string]server
+	}
+
+	base := node{ID: 1, Owner: map[string]server{"main": {Host: "a", Port: 1}}}
+	ours := node{ID: 1, Owner: map[string]server{"main": {Host: "b", Port: 2}}}
+	theirs := node{ID: 1, Owner: map[string]server{"main": {Host: "c", Port: 2}}}
+
+	_, conflicts, err := libra.Merge(context.Background(), base, ours, theirs)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	want := []libra.MergeConflict{{
+		ObjectType: "libra_test.node",
+		ObjectID:   "1",
+		Field:      "Owner.main.Host",
+		Base:       "a",
+		Ours:       []diff.Diff{{ChangeType: diff.Changed, ObjectType: "libra_test.node", ObjectID: "1", Field: "Owner.main.Host", Old: "a", New: "b"}},
+		Theirs:     []diff.Diff{{ChangeType: diff.Changed, ObjectType: "libra_test.node", ObjectID: "1", Field: "Owner.main.Host", Old: "a", New: "c"}},
+	}}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("Merge() conflicts = %+v, want %+v", conflicts, want)
+	}
+}
+
+func ExampleMerge() {
+	base := config{ID: 1, Name: "app", Timeout: 10, Server: server{Host: "localhost", Port: 80}}
+	ours := config{ID: 1, Name: "app", Timeout: 30, Server: server{Host: "localhost", Port: 8080}}
+	theirs := config{ID: 1, Name: "web", Timeout: 20, Server: server{Host: "localhost", Port: 80}}
+
+	merged, conflicts, err := libra.Merge(context.Background(), base, ours, theirs)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%+v\n", merged)
+	for _, c := range conflicts {
+		values := []string{}
+		for _, d := range append(c.Ours, c.Theirs...) {
+			values = append(values, fmt.Sprintf("%v", d.New))
+		}
+		fmt.Printf("%s: %v -> %s\n", c.Field, c.Base, strings.Join(values, " or "))
+	}
+	// Output:
+	// {ID:1 Name:web Timeout:10 Tags:[] Server:{Host:localhost Port:8080} Labels:map[]}
+	// Timeout: 10 -> 30 or 20
+}
@@ -0,0 +1,32 @@
+package libra
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+//Patch is used to apply the differences onto the target, which should be a pointer to a struct or a map.
+//It returns *patcher.ConflictError when the current value of the target is not equal to the old value of the diffs
+func Patch(ctx context.Context, target interface{}, diffs []diff.Diff) error {
+	targetVal := reflect.ValueOf(target)
+
+	switch targetVal.Kind() {
+	case reflect.Ptr:
+		if targetVal.IsNil() {
+			return fmt.Errorf("target cannot be nil")
+		}
+		targetVal = targetVal.Elem()
+	case reflect.Map:
+		if targetVal.IsNil() {
+			return fmt.Errorf("target cannot be nil")
+		}
+	default:
+		return fmt.Errorf("target should be a pointer or a map")
+	}
+
+	return patcher.Apply(ctx, targetVal, diffs)
+}
@@ -0,0 +1,168 @@
+package libra_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+func TestPatch(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		target interface{}
+		diffs  []diff.Diff
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			"succeed when patch the struct pointer",
+			args{
+				ctx:    nil,
+				target: &person{ID: 1, Name: "test1"},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "libra_test.person",
+					ObjectID:   "1",
+					Field:      "Name",
+					Old:        "test1",
+					New:        "test2",
+				}},
+			},
+			&person{ID: 1, Name: "test2"},
+			false,
+		}, {
+			"succeed when patch the map",
+			args{
+				ctx:    nil,
+				target: map[string]interface{}{"Age": 22},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "map[string]interface {}",
+					Field:      "Age",
+					Old:        22,
+					New:        23,
+				}},
+			},
+			map[string]interface{}{"Age": 23},
+			false,
+		}, {
+			"succeed when patch the map with integer keys",
+			args{
+				ctx:    nil,
+				target: map[int]string{1: "a", 2: "b"},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "map[int]string",
+					Field:      "1",
+					Old:        "a",
+					New:        "c",
+				}, {
+					ChangeType: diff.Removed,
+					ObjectType: "map[int]string",
+					Field:      "2",
+					Old:        "b",
+				}, {
+					ChangeType: diff.New,
+					ObjectType: "map[int]string",
+					Field:      "3",
+					New:        "d",
+				}},
+			},
+			map[int]string{1: "c", 3: "d"},
+			false,
+		}, {
+			"failed when a new value of the struct could not be converted",
+			args{
+				ctx:    nil,
+				target: &person{ID: 1, Name: "a", Age: 1},
+				diffs: []diff.Diff{
+					{ChangeType: diff.Changed, Field: "Name", Old: "a", New: "b"},
+					{ChangeType: diff.Changed, Field: "Age", Old: 1, New: "abc"},
+				},
+			},
+			&person{ID: 1, Name: "a", Age: 1},
+			true,
+		}, {
+			"failed when a new value of the map could not be converted",
+			args{
+				ctx:    nil,
+				target: map[string]int{"a": 1, "b": 2},
+				diffs: []diff.Diff{
+					{ChangeType: diff.Changed, Field: "a", Old: 1, New: 3},
+					{ChangeType: diff.Removed, Field: "b", Old: 2},
+					{ChangeType: diff.New, Field: "c", New: "abc"},
+				},
+			},
+			map[string]int{"a": 1, "b": 2},
+			true,
+		}, {
+			"failed when the target is not a pointer",
+			args{
+				ctx:    nil,
+				target: person{ID: 1, Name: "test1"},
+				diffs:  []diff.Diff{},
+			},
+			person{ID: 1, Name: "test1"},
+			true,
+		}, {
+			"failed when the target is nil",
+			args{
+				ctx:    nil,
+				target: (*person)(nil),
+				diffs:  []diff.Diff{},
+			},
+			(*person)(nil),
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := libra.Patch(tt.args.ctx, tt.args.target, tt.args.diffs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Patch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(tt.args.target, tt.want) {
+				t.Errorf("Patch() = %v, want %v", tt.args.target, tt.want)
+			}
+		})
+	}
+}
+
+func ExamplePatch() {
+	oldPerson := person{
+		Name:    "Gopher",
+		Age:     10,
+		Weight:  50.0,
+		Hobbies: []string{"Coding"},
+	}
+
+	newPerson := person{
+		Name:    "Gopher",
+		Age:     11,
+		Weight:  60.0,
+		Hobbies: []string{"Coding", "Hacking"},
+	}
+
+	diffs, err := libra.Compare(context.Background(), oldPerson, newPerson)
+	if err != nil {
+		panic(err)
+	}
+
+	replica := oldPerson
+	if err := libra.Patch(context.Background(), &replica, diffs); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(reflect.DeepEqual(replica, newPerson))
+	// Output:
+	// true
+}
@@ -0,0 +1,251 @@
+package codec
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//CSVHeader is the columns of the CSV which is written by CSVEncoder
+var CSVHeader = []string{"change_type", "object_type", "object_id", "path", "old", "new"}
+
+//ValueEncoder is used to encode a non-scalar value into a CSV cell
+type ValueEncoder func(v interface{}) (string, error)
+
+//ValueDecoder is used to decode a CSV cell into a value
+type ValueDecoder func(s string) (interface{}, error)
+
+//JSONValueEncoder encodes the non-scalar values as JSON, it is the default ValueEncoder
+func JSONValueEncoder(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+//JSONValueDecoder decodes the JSON objects and arrays and keeps the other cells as string, it is the default ValueDecoder
+func JSONValueDecoder(s string) (interface{}, error) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return s, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+		return s, nil
+	}
+
+	return v, nil
+}
+
+//CSVEncoder writes the diffs into CSV. The cells which start with =, +, -, @, a tab or a carriage return are prefixed
+//by a single quote, so a spreadsheet does not evaluate them as formulas. CSVDecoder removes the prefix again
+type CSVEncoder struct {
+	w *csv.Writer
+
+	//EncodeValue encodes the non-scalar values, the default is JSONValueEncoder
+	EncodeValue ValueEncoder
+
+	wroteHeader bool
+}
+
+//NewCSVEncoder returns a new CSVEncoder which writes into w
+func NewCSVEncoder(w io.Writer) *CSVEncoder {
+	return &CSVEncoder{w: csv.NewWriter(w)}
+}
+
+//Encode writes the header on the first call, then a row for each of the diffs
+func (e *CSVEncoder) Encode(diffs []diff.Diff) error {
+	if !e.wroteHeader {
+		if err := e.w.Write(CSVHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	for i, d := range diffs {
+		oldCell, err := e.cell(d.Old)
+		if err != nil {
+			return fmt.Errorf("error on encode old value of diff %d Error : %s", i, err.Error())
+		}
+
+		newCell, err := e.cell(d.New)
+		if err != nil {
+			return fmt.Errorf("error on encode new value of diff %d Error : %s", i, err.Error())
+		}
+
+		row := []string{string(d.ChangeType), escapeFormula(d.ObjectType), escapeFormula(d.ObjectID), escapeFormula(d.Field), oldCell, newCell}
+		if err := e.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *CSVEncoder) cell(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	if n, ok := v.(json.Number); ok {
+		return n.String(), nil
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String:
+		return escapeFormula(fmt.Sprintf("%v", v)), nil
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%v", v), nil
+	}
+
+	encode := e.EncodeValue
+	if encode == nil {
+		encode = JSONValueEncoder
+	}
+
+	cell, err := encode(v)
+	if err != nil {
+		return "", err
+	}
+
+	return escapeFormula(cell), nil
+}
+
+//isFormula reports whether a spreadsheet could evaluate the cell as a formula, or whether the cell starts with
+//the single quote of an escaped formula, so the escaping could be reversed
+func isFormula(cell string) bool {
+	if cell == "" {
+		return false
+	}
+
+	switch cell[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return true
+	case '\'':
+		return isFormula(cell[1:])
+	default:
+		return false
+	}
+}
+
+func escapeFormula(cell string) string {
+	if isFormula(cell) {
+		return "'" + cell
+	}
+
+	return cell
+}
+
+func unescapeFormula(cell string) string {
+	if strings.HasPrefix(cell, "'") && isFormula(cell[1:]) {
+		return cell[1:]
+	}
+
+	return cell
+}
+
+//CSVDecoder reads the diffs from CSV which is written by CSVEncoder.
+//The columns are matched by the header, so they could be reordered in a spreadsheet
+type CSVDecoder struct {
+	r *csv.Reader
+
+	//DecodeValue decodes the old and new cells, the default is JSONValueDecoder
+	DecodeValue ValueDecoder
+}
+
+//NewCSVDecoder returns a new CSVDecoder which reads from r
+func NewCSVDecoder(r io.Reader) *CSVDecoder {
+	return &CSVDecoder{r: csv.NewReader(r)}
+}
+
+//Decode reads all of the rows into diffs
+func (d *CSVDecoder) Decode() ([]diff.Diff, error) {
+	header, err := d.r.Read()
+	if err == io.EOF {
+		return []diff.Diff{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	for _, name := range CSVHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %s is not found", name)
+		}
+	}
+
+	diffs := []diff.Diff{}
+	for line := 2; ; line++ {
+		record, err := d.r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := d.decodeRecord(columns, record)
+		if err != nil {
+			return nil, fmt.Errorf("error on decode line %d Error : %s", line, err.Error())
+		}
+		diffs = append(diffs, result)
+	}
+
+	return diffs, nil
+}
+
+func (d *CSVDecoder) decodeRecord(columns map[string]int, record []string) (diff.Diff, error) {
+	result := diff.Diff{
+		ChangeType: diff.ChangeType(record[columns["change_type"]]),
+		ObjectType: unescapeFormula(record[columns["object_type"]]),
+		ObjectID:   unescapeFormula(record[columns["object_id"]]),
+		Field:      unescapeFormula(record[columns["path"]]),
+	}
+
+	switch result.ChangeType {
+	case diff.New, diff.Removed, diff.Changed:
+	default:
+		return diff.Diff{}, fmt.Errorf("unknown change type %s", result.ChangeType)
+	}
+
+	var err error
+	if result.ChangeType != diff.New {
+		if result.Old, err = d.value(record[columns["old"]]); err != nil {
+			return diff.Diff{}, err
+		}
+	}
+
+	if result.ChangeType != diff.Removed {
+		if result.New, err = d.value(record[columns["new"]]); err != nil {
+			return diff.Diff{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func (d *CSVDecoder) value(s string) (interface{}, error) {
+	s = unescapeFormula(s)
+	if d.DecodeValue != nil {
+		return d.DecodeValue(s)
+	}
+
+	return JSONValueDecoder(s)
+}
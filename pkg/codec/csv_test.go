@@ -0,0 +1,244 @@
+package codec_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+type person struct {
+	ID      int `libra:"id"`
+	Name    string
+	Age     int
+	Hobbies []string
+}
+
+func TestCSVEncoder_Encode(t *testing.T) {
+	tests := []struct {
+		name        string
+		encodeValue codec.ValueEncoder
+		diffs       []diff.Diff
+		want        string
+		wantErr     bool
+	}{
+		{
+			"succeed when encode the diffs",
+			nil,
+			[]diff.Diff{{
+				ChangeType: diff.Changed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Name",
+				Old:        "test1",
+				New:        "test, 2",
+			}, {
+				ChangeType: diff.New,
+				ObjectType: "map[string]interface {}",
+				Field:      "Address",
+				New:        map[string]interface{}{"City": "Malang"},
+			}, {
+				ChangeType: diff.Removed,
+				ObjectType: "map[string]interface {}",
+				Field:      "Age",
+				Old:        22,
+			}},
+			"change_type,object_type,object_id,path,old,new\n" +
+				"changed,codec_test.person,1,Name,test1,\"test, 2\"\n" +
+				"new,map[string]interface {},,Address,,\"{\"\"City\"\":\"\"Malang\"\"}\"\n" +
+				"removed,map[string]interface {},,Age,22,\n",
+			false,
+		}, {
+			"succeed when encode with custom value encoder",
+			func(v interface{}) (string, error) {
+				return "<complex>", nil
+			},
+			[]diff.Diff{{
+				ChangeType: diff.New,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				New:        person{ID: 1},
+			}},
+			"change_type,object_type,object_id,path,old,new\n" +
+				"new,codec_test.person,1,,,<complex>\n",
+			false,
+		}, {
+			"succeed when escape the formulas",
+			nil,
+			[]diff.Diff{{
+				ChangeType: diff.Changed,
+				ObjectType: "map[string]interface {}",
+				ObjectID:   "=1+1",
+				Field:      "@total",
+				Old:        "-2+3",
+				New:        "+cmd",
+			}, {
+				ChangeType: diff.Changed,
+				ObjectType: "map[string]interface {}",
+				Field:      "note",
+				Old:        "'=a",
+				New:        "'a",
+			}, {
+				ChangeType: diff.Changed,
+				ObjectType: "map[string]interface {}",
+				Field:      "price",
+				Old:        -1,
+				New:        json.Number("-2"),
+			}},
+			"change_type,object_type,object_id,path,old,new\n" +
+				"changed,map[string]interface {},'=1+1,'@total,'-2+3,'+cmd\n" +
+				"changed,map[string]interface {},,note,''=a,'a\n" +
+				"changed,map[string]interface {},,price,-1,-2\n",
+			false,
+		}, {
+			"failed when the value could not be encoded",
+			nil,
+			[]diff.Diff{{
+				ChangeType: diff.New,
+				ObjectType: "func()",
+				New:        func() {},
+			}},
+			"change_type,object_type,object_id,path,old,new\n",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			e := codec.NewCSVEncoder(w)
+			e.EncodeValue = tt.encodeValue
+			if err := e.Encode(tt.diffs); (err != nil) != tt.wantErr {
+				t.Errorf("CSVEncoder.Encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("CSVEncoder.Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVDecoder_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when decode the reordered columns",
+			"object_type,object_id,change_type,path,new,old\n" +
+				"codec_test.person,1,changed,Name,test2,test1\n" +
+				"codec_test.person,1,changed,Hobbies,\"[\"\"Coding\"\"]\",\n" +
+				"codec_test.person,1,removed,Age,,22\n",
+			[]diff.Diff{{
+				ChangeType: diff.Changed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Name",
+				Old:        "test1",
+				New:        "test2",
+			}, {
+				ChangeType: diff.Changed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Hobbies",
+				Old:        "",
+				New:        []interface{}{"Coding"},
+			}, {
+				ChangeType: diff.Removed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Age",
+				Old:        "22",
+			}},
+			false,
+		}, {
+			"succeed when decode the escaped formulas",
+			"change_type,object_type,object_id,path,old,new\n" +
+				"changed,map,'=1+1,'@total,'-2+3,'+cmd\n" +
+				"changed,map,,note,''=a,'a\n",
+			[]diff.Diff{{
+				ChangeType: diff.Changed,
+				ObjectType: "map",
+				ObjectID:   "=1+1",
+				Field:      "@total",
+				Old:        "-2+3",
+				New:        "+cmd",
+			}, {
+				ChangeType: diff.Changed,
+				ObjectType: "map",
+				Field:      "note",
+				Old:        "'=a",
+				New:        "'a",
+			}},
+			false,
+		}, {
+			"succeed when decode the empty input",
+			"",
+			[]diff.Diff{},
+			false,
+		}, {
+			"failed when the column is missing",
+			"change_type,object_type,path,old,new\n",
+			nil,
+			true,
+		}, {
+			"failed when the change type is unknown",
+			"change_type,object_type,object_id,path,old,new\n" +
+				"moved,codec_test.person,1,Name,a,b\n",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec.NewCSVDecoder(strings.NewReader(tt.input)).Decode()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CSVDecoder.Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CSVDecoder.Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSV_Patch(t *testing.T) {
+	oldPerson := person{ID: 1, Name: "test1", Age: 22, Hobbies: []string{"Coding"}}
+	newPerson := person{ID: 1, Name: "test2", Age: 23, Hobbies: []string{"Coding", "Hiking"}}
+
+	diffs, err := libra.Compare(context.Background(), oldPerson, newPerson)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	w := &bytes.Buffer{}
+	if err := codec.NewCSVEncoder(w).Encode(diffs); err != nil {
+		t.Fatalf("CSVEncoder.Encode() error = %v", err)
+	}
+
+	imported, err := codec.NewCSVDecoder(w).Decode()
+	if err != nil {
+		t.Fatalf("CSVDecoder.Decode() error = %v", err)
+	}
+
+	patched := oldPerson
+	if err := libra.Patch(context.Background(), &patched, imported); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(patched, newPerson) {
+		t.Errorf("Patch() = %v, want %v", patched, newPerson)
+	}
+}
@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//JSONDiff is the JSON representation of a diff. Unlike diff.Diff, the old and new values are written even when
+//they are false, zero or empty, so the diffs of the JSON documents could be decoded without losing their values
+type JSONDiff struct {
+	ChangeType diff.ChangeType `json:"change_type"`
+	ObjectType string          `json:"object_type"`
+	ObjectID   string          `json:"object_id"`
+	Field      string          `json:"field,omitempty"`
+	Old        json.RawMessage `json:"old,omitempty"`
+	New        json.RawMessage `json:"new,omitempty"`
+}
+
+//EncodeJSONDiff converts the diff into JSONDiff, the old value is written unless the change type is New
+//and the new value is written unless the change type is Removed
+func EncodeJSONDiff(d diff.Diff) (JSONDiff, error) {
+	encoded := JSONDiff{ChangeType: d.ChangeType, ObjectType: d.ObjectType, ObjectID: d.ObjectID, Field: d.Field}
+
+	var err error
+	if d.ChangeType != diff.New {
+		if encoded.Old, err = json.Marshal(d.Old); err != nil {
+			return JSONDiff{}, err
+		}
+	}
+
+	if d.ChangeType != diff.Removed {
+		if encoded.New, err = json.Marshal(d.New); err != nil {
+			return JSONDiff{}, err
+		}
+	}
+
+	return encoded, nil
+}
+
+//DecodeJSONDiff converts JSONDiff into the diff which values are decoded into the generic values,
+//the numbers are decoded as json.Number so they keep their precision
+func DecodeJSONDiff(d JSONDiff) (diff.Diff, error) {
+	decoded := diff.Diff{ChangeType: d.ChangeType, ObjectType: d.ObjectType, ObjectID: d.ObjectID, Field: d.Field}
+	if len(d.Old) > 0 {
+		if err := decodeNumber(d.Old, &decoded.Old); err != nil {
+			return diff.Diff{}, err
+		}
+	}
+
+	if len(d.New) > 0 {
+		if err := decodeNumber(d.New, &decoded.New); err != nil {
+			return diff.Diff{}, err
+		}
+	}
+
+	return decoded, nil
+}
+
+//decodeNumber decodes the JSON value like json.Unmarshal, except the numbers are decoded as json.Number
+func decodeNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
+
+//EncodeJSONDiffs converts the diffs into JSONDiff
+func EncodeJSONDiffs(diffs []diff.Diff) ([]JSONDiff, error) {
+	encoded := make([]JSONDiff, 0, len(diffs))
+	for _, d := range diffs {
+		e, err := EncodeJSONDiff(d)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, e)
+	}
+
+	return encoded, nil
+}
+
+//DecodeJSONDiffs converts JSONDiff into the diffs, the change types should be New, Removed or Changed
+func DecodeJSONDiffs(encoded []JSONDiff) ([]diff.Diff, error) {
+	diffs := make([]diff.Diff, 0, len(encoded))
+	for i, d := range encoded {
+		switch d.ChangeType {
+		case diff.New, diff.Removed, diff.Changed:
+		default:
+			return nil, fmt.Errorf("unsupported change type %q of diff %d", d.ChangeType, i)
+		}
+
+		decoded, err := DecodeJSONDiff(d)
+		if err != nil {
+			return nil, fmt.Errorf("error on decode diff %d Error : %s", i, err.Error())
+		}
+		diffs = append(diffs, decoded)
+	}
+
+	return diffs, nil
+}
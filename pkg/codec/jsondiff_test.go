@@ -0,0 +1,54 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+func TestJSONDiff(t *testing.T) {
+	diffs := []diff.Diff{
+		{ChangeType: diff.Changed, ObjectType: "config", Field: "debug", Old: false, New: true},
+		{ChangeType: diff.Changed, Field: "port", Old: json.Number("9007199254740993"), New: ""},
+		{ChangeType: diff.New, Field: "tls", New: nil},
+		{ChangeType: diff.Removed, Field: "tags", Old: []interface{}{"a"}},
+	}
+
+	encoded, err := codec.EncodeJSONDiffs(diffs)
+	if err != nil {
+		t.Fatalf("EncodeJSONDiffs() error = %v", err)
+	}
+
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	want := `[{"change_type":"changed","object_type":"config","object_id":"","field":"debug","old":false,"new":true},` +
+		`{"change_type":"changed","object_type":"","object_id":"","field":"port","old":9007199254740993,"new":""},` +
+		`{"change_type":"new","object_type":"","object_id":"","field":"tls","new":null},` +
+		`{"change_type":"removed","object_type":"","object_id":"","field":"tags","old":["a"]}]`
+	if string(b) != want {
+		t.Errorf("EncodeJSONDiffs() = %s, want %s", b, want)
+	}
+
+	decoded := []codec.JSONDiff{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	got, err := codec.DecodeJSONDiffs(decoded)
+	if err != nil {
+		t.Fatalf("DecodeJSONDiffs() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, diffs) {
+		t.Errorf("DecodeJSONDiffs() = %v, want %v", got, diffs)
+	}
+
+	if _, err := codec.DecodeJSONDiffs([]codec.JSONDiff{{ChangeType: "moved"}}); err == nil {
+		t.Errorf("DecodeJSONDiffs() error = nil, want unsupported change type")
+	}
+}
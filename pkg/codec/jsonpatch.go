@@ -0,0 +1,135 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+//JSONPatchOperation is an operation of JSON Patch (RFC 6902)
+type JSONPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+//MarshalJSON keeps the null value of the operations which require a value
+func (o JSONPatchOperation) MarshalJSON() ([]byte, error) {
+	if o.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{o.Op, o.Path, o.Value})
+}
+
+//EncodeJSONPatch converts the diffs into the JSON Patch operations, which are ordered so the array indexes stay valid.
+//A test operation of the old value is written before each replace and remove operation when test is true
+func EncodeJSONPatch(diffs []diff.Diff, test bool) []JSONPatchOperation {
+	ops := make([]JSONPatchOperation, 0, len(diffs))
+	for _, d := range patcher.Order(diffs) {
+		path := JSONPointer(d.Field)
+		if test && d.ChangeType != diff.New {
+			ops = append(ops, JSONPatchOperation{Op: "test", Path: path, Value: d.Old})
+		}
+
+		switch d.ChangeType {
+		case diff.New:
+			ops = append(ops, JSONPatchOperation{Op: "add", Path: path, Value: d.New})
+		case diff.Removed:
+			ops = append(ops, JSONPatchOperation{Op: "remove", Path: path})
+		default:
+			ops = append(ops, JSONPatchOperation{Op: "replace", Path: path, Value: d.New})
+		}
+	}
+
+	return ops
+}
+
+//DecodeJSONPatch converts the JSON Patch operations into the diffs. The test operation gives the old value
+//of the following operation on the same path, otherwise it is kept as a change into the same value
+func DecodeJSONPatch(ops []JSONPatchOperation) ([]diff.Diff, error) {
+	diffs := []diff.Diff{}
+	for i := 0; i < len(ops); i++ {
+		op := ops[i]
+		field, err := ParseJSONPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error on decode operation %d Error : %s", i, err.Error())
+		}
+
+		d := diff.Diff{Field: field}
+		if op.Op == "test" {
+			d.Old = op.Value
+			if i+1 < len(ops) && ops[i+1].Path == op.Path && (ops[i+1].Op == "replace" || ops[i+1].Op == "remove") {
+				i++
+				op = ops[i]
+			} else {
+				d.ChangeType = diff.Changed
+				d.New = op.Value
+				diffs = append(diffs, d)
+				continue
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			d.ChangeType = diff.New
+			d.New = op.Value
+		case "remove":
+			d.ChangeType = diff.Removed
+		case "replace":
+			d.ChangeType = diff.Changed
+			d.New = op.Value
+		default:
+			return nil, fmt.Errorf("error on decode operation %d Error : unsupported operation %s", i, op.Op)
+		}
+		diffs = append(diffs, d)
+	}
+
+	return diffs, nil
+}
+
+//JSONPointer converts the field of a diff into JSON Pointer (RFC 6901)
+func JSONPointer(field string) string {
+	if field == "" {
+		return ""
+	}
+
+	segments := patcher.SplitField(field)
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+//ParseJSONPointer converts JSON Pointer (RFC 6901) into the field of a diff
+func ParseJSONPointer(pointer string) (string, error) {
+	if pointer == "" {
+		return "", nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return "", fmt.Errorf("invalid JSON pointer %s", pointer)
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		if segment == "" {
+			return "", fmt.Errorf("unsupported segment %q of JSON pointer %s", segment, pointer)
+		}
+		segments[i] = segment
+	}
+
+	return diff.JoinField(segments...), nil
+}
@@ -0,0 +1,116 @@
+package codec_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+func TestEncodeJSONPatch(t *testing.T) {
+	diffs := []diff.Diff{
+		{ChangeType: diff.Removed, Field: "tags.1", Old: "b"},
+		{ChangeType: diff.Removed, Field: "tags.2", Old: "c"},
+		{ChangeType: diff.New, Field: "owner", New: nil},
+		{ChangeType: diff.Changed, Field: "a/b.c~d", Old: 1.0, New: 2.0},
+		{ChangeType: diff.Changed, Field: `x\.y`, Old: 1.0, New: 2.0},
+	}
+
+	tests := []struct {
+		name string
+		test bool
+		want string
+	}{
+		{
+			"succeed when encode the operations",
+			false,
+			`[{"op":"replace","path":"/a~1b/c~0d","value":2},{"op":"replace","path":"/x.y","value":2},` +
+				`{"op":"add","path":"/owner","value":null},` +
+				`{"op":"remove","path":"/tags/2"},` +
+				`{"op":"remove","path":"/tags/1"}]`,
+		}, {
+			"succeed when encode the operations with test",
+			true,
+			`[{"op":"test","path":"/a~1b/c~0d","value":1},{"op":"replace","path":"/a~1b/c~0d","value":2},` +
+				`{"op":"test","path":"/x.y","value":1},{"op":"replace","path":"/x.y","value":2},` +
+				`{"op":"add","path":"/owner","value":null},` +
+				`{"op":"test","path":"/tags/2","value":"c"},{"op":"remove","path":"/tags/2"},` +
+				`{"op":"test","path":"/tags/1","value":"b"},{"op":"remove","path":"/tags/1"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(codec.EncodeJSONPatch(diffs, tt.test))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EncodeJSONPatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     string
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when decode the operations with test",
+			`[{"op":"test","path":"/name","value":"a"},{"op":"replace","path":"/name","value":"b"},
+			  {"op":"test","path":"/tags/0","value":"x"},{"op":"remove","path":"/tags/0"},
+			  {"op":"add","path":"/a~1b","value":{"c":1}}]`,
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "name", Old: "a", New: "b"},
+				{ChangeType: diff.Removed, Field: "tags.0", Old: "x"},
+				{ChangeType: diff.New, Field: "a/b", New: map[string]interface{}{"c": 1.0}},
+			},
+			false,
+		}, {
+			"succeed when decode the operations without test",
+			`[{"op":"replace","path":"","value":[1]},{"op":"test","path":"/age","value":10}]`,
+			[]diff.Diff{
+				{ChangeType: diff.Changed, New: []interface{}{1.0}},
+				{ChangeType: diff.Changed, Field: "age", Old: 10.0, New: 10.0},
+			},
+			false,
+		}, {
+			"failed when decode the unsupported operation",
+			`[{"op":"move","path":"/a","from":"/b"}]`,
+			nil,
+			true,
+		}, {
+			"succeed when decode the path with dots",
+			`[{"op":"add","path":"/a.b/c\\d","value":1}]`,
+			[]diff.Diff{{ChangeType: diff.New, Field: `a\.b.c\\d`, New: 1.0}},
+			false,
+		}, {
+			"failed when decode the invalid path",
+			`[{"op":"add","path":"a","value":1}]`,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := []codec.JSONPatchOperation{}
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+
+			got, err := codec.DecodeJSONPatch(ops)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeJSONPatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//TypedValue is a JSON encoded value along with the name of its Go type
+type TypedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+//TypedDiff is the type-preserving representation of diff.Diff
+type TypedDiff struct {
+	ChangeType diff.ChangeType `json:"change_type"`
+	ObjectType string          `json:"object_type"`
+	ObjectID   string          `json:"object_id"`
+	Field      string          `json:"field,omitempty"`
+	Old        *TypedValue     `json:"old,omitempty"`
+	New        *TypedValue     `json:"new,omitempty"`
+}
+
+//Registry resolves the type names of TypedValue back into the Go types.
+//The named types, e.g. structs, should be registered before decoding, while the slices, arrays,
+//maps and pointers of the known types are resolved automatically
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+//NewRegistry returns a Registry with the predeclared types and time.Time registered
+func NewRegistry() *Registry {
+	r := &Registry{types: map[string]reflect.Type{}}
+	r.Register(
+		false, "",
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0), uintptr(0),
+		float32(0), float64(0),
+		time.Time{}, time.Duration(0),
+	)
+	r.RegisterType(reflect.TypeOf((*interface{})(nil)).Elem())
+
+	return r
+}
+
+//Register registers the types of the given values
+func (r *Registry) Register(values ...interface{}) {
+	for _, v := range values {
+		r.RegisterType(reflect.TypeOf(v))
+	}
+}
+
+//RegisterType registers the given type
+func (r *Registry) RegisterType(t reflect.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.types[TypeName(t)] = t
+}
+
+//Lookup resolves the type name into the Go type
+func (r *Registry) Lookup(name string) (reflect.Type, error) {
+	r.mu.RLock()
+	t, ok := r.types[name]
+	r.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	switch {
+	case strings.HasPrefix(name, "*"):
+		elem, err := r.Lookup(name[1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(elem), nil
+	case strings.HasPrefix(name, "[]"):
+		elem, err := r.Lookup(name[2:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case strings.HasPrefix(name, "["):
+		end := strings.Index(name, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid type %s", name)
+		}
+		length, err := strconv.Atoi(name[1:end])
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("invalid type %s", name)
+		}
+		elem, err := r.Lookup(name[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ArrayOf(length, elem), nil
+	case strings.HasPrefix(name, "map["):
+		end := matchingBracket(name, len("map"))
+		if end < 0 {
+			return nil, fmt.Errorf("invalid type %s", name)
+		}
+		key, err := r.Lookup(name[len("map["):end])
+		if err != nil {
+			return nil, err
+		}
+		elem, err := r.Lookup(name[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(key, elem), nil
+	}
+
+	return nil, &UnregisteredTypeError{Type: name}
+}
+
+//UnregisteredTypeError is returned by Registry.Lookup when the type name is well-formed but is not registered
+type UnregisteredTypeError struct {
+	Type string
+}
+
+func (e *UnregisteredTypeError) Error() string {
+	return fmt.Sprintf("type %s is not registered", e.Type)
+}
+
+//TypeName returns the name of the type which is used by TypedValue.
+//The named types are qualified by their full package path to avoid collisions
+func TypeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + TypeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + TypeName(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), TypeName(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", TypeName(t.Key()), TypeName(t.Elem()))
+	}
+
+	return t.String()
+}
+
+func matchingBracket(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+//EncodeValue encodes the value into TypedValue. It returns nil when the value is nil
+func EncodeValue(v interface{}) (*TypedValue, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedValue{Type: TypeName(reflect.TypeOf(v)), Value: b}, nil
+}
+
+//DecodeValue decodes the TypedValue into a value of its original type
+func DecodeValue(tv *TypedValue, registry *Registry) (interface{}, error) {
+	if tv == nil {
+		return nil, nil
+	}
+
+	t, err := registry.Lookup(tv.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(tv.Value, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("error on decode %s Error : %s", tv.Type, err.Error())
+	}
+
+	return ptr.Elem().Interface(), nil
+}
+
+//EncodeTyped converts the diff into TypedDiff
+func EncodeTyped(d diff.Diff) (TypedDiff, error) {
+	oldValue, err := EncodeValue(d.Old)
+	if err != nil {
+		return TypedDiff{}, fmt.Errorf("error on encode old value of %s Error : %s", d.Field, err.Error())
+	}
+
+	newValue, err := EncodeValue(d.New)
+	if err != nil {
+		return TypedDiff{}, fmt.Errorf("error on encode new value of %s Error : %s", d.Field, err.Error())
+	}
+
+	return TypedDiff{
+		ChangeType: d.ChangeType,
+		ObjectType: d.ObjectType,
+		ObjectID:   d.ObjectID,
+		Field:      d.Field,
+		Old:        oldValue,
+		New:        newValue,
+	}, nil
+}
+
+//DecodeTyped converts the TypedDiff back into diff.Diff
+func DecodeTyped(td TypedDiff, registry *Registry) (diff.Diff, error) {
+	oldValue, err := DecodeValue(td.Old, registry)
+	if err != nil {
+		return diff.Diff{}, fmt.Errorf("error on decode old value of %s Error : %s", td.Field, err.Error())
+	}
+
+	newValue, err := DecodeValue(td.New, registry)
+	if err != nil {
+		return diff.Diff{}, fmt.Errorf("error on decode new value of %s Error : %s", td.Field, err.Error())
+	}
+
+	return diff.Diff{
+		ChangeType: td.ChangeType,
+		ObjectType: td.ObjectType,
+		ObjectID:   td.ObjectID,
+		Field:      td.Field,
+		Old:        oldValue,
+		New:        newValue,
+	}, nil
+}
+
+//MarshalTyped encodes the diffs as a JSON array of TypedDiff
+func MarshalTyped(diffs []diff.Diff) ([]byte, error) {
+	typedDiffs := make([]TypedDiff, 0, len(diffs))
+	for _, d := range diffs {
+		td, err := EncodeTyped(d)
+		if err != nil {
+			return nil, err
+		}
+		typedDiffs = append(typedDiffs, td)
+	}
+
+	return json.Marshal(typedDiffs)
+}
+
+//UnmarshalTyped decodes the JSON array of TypedDiff and restores the values with the registry
+func UnmarshalTyped(data []byte, registry *Registry) ([]diff.Diff, error) {
+	typedDiffs := []TypedDiff{}
+	if err := json.Unmarshal(data, &typedDiffs); err != nil {
+		return nil, err
+	}
+
+	diffs := make([]diff.Diff, 0, len(typedDiffs))
+	for _, td := range typedDiffs {
+		d, err := DecodeTyped(td, registry)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, d)
+	}
+
+	return diffs, nil
+}
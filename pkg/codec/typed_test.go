@@ -0,0 +1,140 @@
+package codec_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+type address struct {
+	Street string
+	City   string
+}
+
+func TestTypeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"predeclared type", int64(1), "int64"},
+		{"named type", address{}, "github.com/haritsfahreza/libra/pkg/codec_test.address"},
+		{"slice of named type", []address{}, "[]github.com/haritsfahreza/libra/pkg/codec_test.address"},
+		{"array", [2]int{}, "[2]int"},
+		{"map", map[string]interface{}{}, "map[string]interface {}"},
+		{"pointer", &address{}, "*github.com/haritsfahreza/libra/pkg/codec_test.address"},
+		{"time", time.Time{}, "time.Time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codec.TypeName(reflect.TypeOf(tt.value)); got != tt.want {
+				t.Errorf("TypeName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalTyped(t *testing.T) {
+	registry := codec.NewRegistry()
+	registry.Register(address{})
+
+	tests := []struct {
+		name     string
+		diffs    []diff.Diff
+		registry *codec.Registry
+		wantErr  bool
+	}{
+		{
+			"succeed when restore the value types",
+			[]diff.Diff{{
+				ChangeType: diff.Changed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Age",
+				Old:        int64(22),
+				New:        int64(23),
+			}, {
+				ChangeType: diff.Changed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "DateOfBirth",
+				Old:        time.Date(2020, time.May, 4, 0, 0, 0, 0, time.UTC),
+				New:        time.Date(2020, time.May, 30, 0, 0, 0, 0, time.UTC),
+			}, {
+				ChangeType: diff.New,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Addresses",
+				New:        map[string][]*address{"home": {{Street: "jalan 123", City: "Malang"}}},
+			}, {
+				ChangeType: diff.Removed,
+				ObjectType: "codec_test.person",
+				ObjectID:   "1",
+				Field:      "Numbers",
+				Old:        [3]uint8{1, 2, 3},
+			}},
+			registry,
+			false,
+		}, {
+			"failed when the type is not registered",
+			[]diff.Diff{{
+				ChangeType: diff.New,
+				ObjectType: "codec_test.address",
+				New:        address{City: "Malang"},
+			}},
+			codec.NewRegistry(),
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := codec.MarshalTyped(tt.diffs)
+			if err != nil {
+				t.Fatalf("MarshalTyped() error = %v", err)
+			}
+
+			got, err := codec.UnmarshalTyped(data, tt.registry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnmarshalTyped() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.diffs) {
+				t.Errorf("UnmarshalTyped() = %v, want %v", got, tt.diffs)
+			}
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		want     reflect.Type
+		wantErr  bool
+	}{
+		{"succeed when resolve the composite type", "map[string][]*[2]int", reflect.TypeOf(map[string][]*[2]int{}), false},
+		{"failed when the array length is not closed", "[x", nil, true},
+		{"failed when the array length is not a number", "[x]int", nil, true},
+		{"failed when the array length is negative", "[-1]int", nil, true},
+		{"failed when the map key is not closed", "map[string", nil, true},
+		{"failed when the type is not registered", "main.Acct", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec.NewRegistry().Lookup(tt.typeName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Lookup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
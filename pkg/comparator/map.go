@@ -3,7 +3,10 @@ package comparator
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
 
 	"github.com/haritsfahreza/libra/pkg/diff"
 )
@@ -16,12 +19,23 @@ func (c *MapComparator) Compare(ctx context.Context, oldVal, newVal reflect.Valu
 	diffs := []diff.Diff{}
 	objectType := oldVal.Type().String()
 	objectID := ""
-	for _, key := range oldVal.MapKeys() {
+	for _, key := range mapKeys(oldVal, newVal) {
+		name := fmt.Sprint(key.Interface())
 		oldField := oldVal.MapIndex(key)
 		newField := newVal.MapIndex(key)
 
+		if !oldField.IsValid() {
+			diffs = append(diffs, diff.Diff{ChangeType: diff.New, Field: diff.JoinField(name), New: newField.Interface()})
+			continue
+		}
+
+		if !newField.IsValid() {
+			diffs = append(diffs, diff.Diff{ChangeType: diff.Removed, Field: diff.JoinField(name), Old: oldField.Interface()})
+			continue
+		}
+
 		if err := Validate(ctx, oldField, newField); err != nil {
-			return nil, fmt.Errorf("error on validate key %s Error : %s", key.String(), err.Error())
+			return nil, fmt.Errorf("error on validate key %s Error : %s", name, err.Error())
 		}
 
 		filteredOldValue := filterValue(oldField)
@@ -29,7 +43,7 @@ func (c *MapComparator) Compare(ctx context.Context, oldVal, newVal reflect.Valu
 		if isNestedKind(filteredOldValue.Kind()) {
 			nestedDiffs, err := compareNestedField(
 				ctx,
-				key.String(),
+				diff.JoinField(name),
 				filteredOldValue,
 				filteredNewValue,
 			)
@@ -45,7 +59,7 @@ func (c *MapComparator) Compare(ctx context.Context, oldVal, newVal reflect.Valu
 			continue
 		}
 
-		if diff := diff.GenerateChangedDiff(ctx, key.String(), filteredOldValue, filteredNewValue); diff != nil {
+		if diff := diff.GenerateChangedDiff(ctx, diff.JoinField(name), filteredOldValue, filteredNewValue); diff != nil {
 			diffs = append(diffs, *diff)
 		}
 	}
@@ -57,3 +71,60 @@ func (c *MapComparator) Compare(ctx context.Context, oldVal, newVal reflect.Valu
 
 	return diffs, nil
 }
+
+//mapKeys returns the keys of both maps in order, the numeric keys come first and are ordered by their value
+func mapKeys(oldVal, newVal reflect.Value) []reflect.Value {
+	keys := oldVal.MapKeys()
+	for _, key := range newVal.MapKeys() {
+		if !oldVal.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprintf("%v", key.Interface())
+	}
+
+	sort.Sort(keySorter{keys: keys, names: names})
+
+	return keys
+}
+
+type keySorter struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (s keySorter) Len() int {
+	return len(s.keys)
+}
+
+func (s keySorter) Less(i, j int) bool {
+	a, aOk := parseNumber(s.names[i])
+	b, bOk := parseNumber(s.names[j])
+	switch {
+	case aOk && bOk && a != b:
+		return a < b
+	case aOk != bOk:
+		//The numeric keys come first
+		return aOk
+	default:
+		return s.names[i] < s.names[j]
+	}
+}
+
+//parseNumber parses the finite numeric key, so the keys like NaN and Inf are ordered as the other names
+func parseNumber(name string) (float64, bool) {
+	f, err := strconv.ParseFloat(name, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+
+	return f, true
+}
+
+func (s keySorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+}
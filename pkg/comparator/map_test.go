@@ -67,6 +67,79 @@ func TestMapComparator_Compare(t *testing.T) {
 				New:        "Reza",
 			}},
 			false,
+		}, {
+			"succeed when the keys exist on one of the maps",
+			args{
+				ctx: nil,
+				old: map[string]interface{}{"Age": 22, "Weight": 80},
+				new: map[string]interface{}{"Age": 22, "Height": 170},
+			},
+			[]diff.Diff{{
+				ChangeType: diff.New,
+				ObjectType: "map[string]interface {}",
+				Field:      "Height",
+				New:        170,
+			}, {
+				ChangeType: diff.Removed,
+				ObjectType: "map[string]interface {}",
+				Field:      "Weight",
+				Old:        80,
+			}},
+			false,
+		}, {
+			"succeed when order the numeric keys by value",
+			args{
+				ctx: nil,
+				old: map[string]string{"2": "a", "10": "b", "x": "c"},
+				new: map[string]string{"2": "d", "10": "e", "x": "f"},
+			},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectType: "map[string]string", Field: "2", Old: "a", New: "d"},
+				{ChangeType: diff.Changed, ObjectType: "map[string]string", Field: "10", Old: "b", New: "e"},
+				{ChangeType: diff.Changed, ObjectType: "map[string]string", Field: "x", Old: "c", New: "f"},
+			},
+			false,
+		}, {
+			"succeed when order the non-finite keys by name",
+			args{
+				ctx: nil,
+				old: map[string]int{"NaN": 1, "Inf": 1, "-1": 1, "1e400": 1, "a": 1, "0.5": 1},
+				new: map[string]int{"NaN": 2, "Inf": 2, "-1": 2, "1e400": 2, "a": 2, "0.5": 2},
+			},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectType: "map[string]int", Field: "-1", Old: 1, New: 2},
+				{ChangeType: diff.Changed, ObjectType: "map[string]int", Field: `0\.5`, Old: 1, New: 2},
+				{ChangeType: diff.Changed, ObjectType: "map[string]int", Field: "1e400", Old: 1, New: 2},
+				{ChangeType: diff.Changed, ObjectType: "map[string]int", Field: "Inf", Old: 1, New: 2},
+				{ChangeType: diff.Changed, ObjectType: "map[string]int", Field: "NaN", Old: 1, New: 2},
+				{ChangeType: diff.Changed, ObjectType: "map[string]int", Field: "a", Old: 1, New: 2},
+			},
+			false,
+		}, {
+			"succeed when escape the dots within the keys",
+			args{
+				ctx: nil,
+				old: map[string]interface{}{"app.name": "a", "Labels": map[string]string{`a\b`: "x"}},
+				new: map[string]interface{}{"app.name": "b", "Labels": map[string]string{`a\b`: "y"}},
+			},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectType: "map[string]interface {}", Field: `Labels.a\\b`, Old: "x", New: "y"},
+				{ChangeType: diff.Changed, ObjectType: "map[string]interface {}", Field: `app\.name`, Old: "a", New: "b"},
+			},
+			false,
+		}, {
+			"succeed when compare the maps with integer keys",
+			args{
+				ctx: nil,
+				old: map[int]string{1: "a", 2: "b"},
+				new: map[int]string{1: "c", 3: "d"},
+			},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectType: "map[int]string", Field: "1", Old: "a", New: "c"},
+				{ChangeType: diff.Removed, ObjectType: "map[int]string", Field: "2", Old: "b"},
+				{ChangeType: diff.New, ObjectType: "map[int]string", Field: "3", New: "d"},
+			},
+			false,
 		}, {
 			"failed when compare the maps with different value type",
 			args{
@@ -0,0 +1,71 @@
+package diff
+
+import (
+	"path"
+	"strings"
+)
+
+//JoinField is used to join the path segments into the field of a diff.
+//The dots and the backslashes within the segments are escaped by a backslash, e.g. `a\.b` for the key `a.b`
+func JoinField(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(segment)
+	}
+
+	return strings.Join(escaped, ".")
+}
+
+//SplitField is used to split the field of a diff into the path segments, it reverses JoinField
+func SplitField(field string) []string {
+	return splitField(field, true)
+}
+
+//MatchField reports whether the field matches the pattern segment by segment, each segment is matched by path.Match.
+//The dots are escaped within the pattern the same way as within the field, e.g. `Labels.app\.*`
+func MatchField(pattern, field string) (bool, error) {
+	patterns := splitField(pattern, false)
+	segments := SplitField(field)
+	if len(patterns) != len(segments) {
+		//The pattern is still checked for ErrBadPattern
+		_, err := path.Match(pattern, "")
+		return false, err
+	}
+
+	for i := range patterns {
+		matched, err := path.Match(patterns[i], segments[i])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+//splitField splits the field by the dots which are not escaped. The escapes are kept when unescape is false,
+//so the segments could be used as path.Match patterns
+func splitField(field string, unescape bool) []string {
+	if field == "" {
+		return []string{}
+	}
+
+	segments := []string{}
+	b := strings.Builder{}
+	for i := 0; i < len(field); i++ {
+		switch c := field[i]; {
+		case c == '\\' && i+1 < len(field):
+			i++
+			if !unescape {
+				b.WriteByte(c)
+			}
+			b.WriteByte(field[i])
+		case c == '.':
+			segments = append(segments, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return append(segments, b.String())
+}
@@ -0,0 +1,110 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+func TestSplitField(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		segments []string
+	}{
+		{
+			"succeed when split the empty field",
+			"",
+			[]string{},
+		}, {
+			"succeed when split the nested field",
+			"Address.Street",
+			[]string{"Address", "Street"},
+		}, {
+			"succeed when split the escaped dots",
+			`a\.b.c`,
+			[]string{"a.b", "c"},
+		}, {
+			"succeed when split the escaped backslashes",
+			`a\\.b\\\.c`,
+			[]string{`a\`, `b\.c`},
+		}, {
+			"succeed when split the empty segments",
+			"a..b",
+			[]string{"a", "", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff.SplitField(tt.field); !reflect.DeepEqual(got, tt.segments) {
+				t.Errorf("SplitField() = %q, want %q", got, tt.segments)
+			}
+			if len(tt.segments) > 0 {
+				if got := diff.JoinField(tt.segments...); got != tt.field {
+					t.Errorf("JoinField() = %q, want %q", got, tt.field)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchField(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		field   string
+		want    bool
+		wantErr bool
+	}{
+		{
+			"succeed when match the segments",
+			"Address.*",
+			"Address.Street",
+			true,
+			false,
+		}, {
+			"succeed when the wildcard does not match the nested segments",
+			"Address.*",
+			"Address.Street.Name",
+			false,
+			false,
+		}, {
+			"succeed when the wildcard matches the escaped dots",
+			"Labels.*",
+			`Labels.app\.kubernetes\.io`,
+			true,
+			false,
+		}, {
+			"succeed when match the escaped dots",
+			`Labels.app\.*`,
+			`Labels.app\.kubernetes\.io`,
+			true,
+			false,
+		}, {
+			"succeed when the escaped dot does not match the separator",
+			`a\.b`,
+			"a.b",
+			false,
+			false,
+		}, {
+			"failed when the pattern is malformed",
+			"Address.[",
+			"Address",
+			false,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diff.MatchField(tt.pattern, tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MatchField() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MatchField() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package diff
+
+//Invert is used to generate the inverse of the diffs, so applying them onto the new value gives back the old value.
+//The order is reversed to undo the latest change first.
+func Invert(diffs []Diff) []Diff {
+	inverted := make([]Diff, 0, len(diffs))
+	for i := len(diffs) - 1; i >= 0; i-- {
+		inverted = append(inverted, InvertDiff(diffs[i]))
+	}
+
+	return inverted
+}
+
+//InvertDiff is used to generate the inverse of a single diff
+func InvertDiff(d Diff) Diff {
+	inverted := d
+	inverted.Old = d.New
+	inverted.New = d.Old
+
+	switch d.ChangeType {
+	case New:
+		inverted.ChangeType = Removed
+	case Removed:
+		inverted.ChangeType = New
+	}
+
+	return inverted
+}
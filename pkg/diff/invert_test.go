@@ -0,0 +1,122 @@
+package diff_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+type person struct {
+	ID      int `libra:"id"`
+	Name    string
+	Age     int
+	Hobbies []string
+}
+
+func TestInvert(t *testing.T) {
+	type args struct {
+		diffs []diff.Diff
+	}
+	tests := []struct {
+		name string
+		args args
+		want []diff.Diff
+	}{
+		{
+			"succeed when invert the changed diffs",
+			args{
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					Field:      "Name",
+					Old:        "test1",
+					New:        "test2",
+				}, {
+					ChangeType: diff.Changed,
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					Field:      "Age",
+					Old:        22,
+					New:        23,
+				}},
+			},
+			[]diff.Diff{{
+				ChangeType: diff.Changed,
+				ObjectType: "diff_test.person",
+				ObjectID:   "1",
+				Field:      "Age",
+				Old:        23,
+				New:        22,
+			}, {
+				ChangeType: diff.Changed,
+				ObjectType: "diff_test.person",
+				ObjectID:   "1",
+				Field:      "Name",
+				Old:        "test2",
+				New:        "test1",
+			}},
+		}, {
+			"succeed when invert the new and removed diffs",
+			args{
+				diffs: []diff.Diff{{
+					ChangeType: diff.New,
+					ObjectType: "map[string]interface {}",
+					Field:      "Height",
+					New:        170,
+				}, {
+					ChangeType: diff.Removed,
+					ObjectType: "map[string]interface {}",
+					Field:      "Weight",
+					Old:        80,
+				}},
+			},
+			[]diff.Diff{{
+				ChangeType: diff.New,
+				ObjectType: "map[string]interface {}",
+				Field:      "Weight",
+				New:        80,
+			}, {
+				ChangeType: diff.Removed,
+				ObjectType: "map[string]interface {}",
+				Field:      "Height",
+				Old:        170,
+			}},
+		}, {
+			"succeed when invert the empty diffs",
+			args{
+				diffs: []diff.Diff{},
+			},
+			[]diff.Diff{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff.Invert(tt.args.diffs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Invert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvert_Patch(t *testing.T) {
+	oldPerson := person{ID: 1, Name: "test1", Age: 22, Hobbies: []string{"Coding"}}
+	newPerson := person{ID: 1, Name: "test2", Age: 23, Hobbies: []string{"Coding", "Hiking"}}
+
+	diffs, err := libra.Compare(context.Background(), oldPerson, newPerson)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	reverted := newPerson
+	if err := libra.Patch(context.Background(), &reverted, diff.Invert(diffs)); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(reverted, oldPerson) {
+		t.Errorf("Patch() = %v, want %v", reverted, oldPerson)
+	}
+}
@@ -0,0 +1,116 @@
+package diff
+
+//DiffNode represents a node of the diffs tree which mirrors the structure of the compared values.
+//The root node holds a child for each object, and each object holds a child for each segment of the changed fields
+type DiffNode struct {
+	Name       string      `json:"name,omitempty"`
+	Path       string      `json:"path,omitempty"`
+	ObjectType string      `json:"object_type,omitempty"`
+	ObjectID   string      `json:"object_id,omitempty"`
+	ChangeType ChangeType  `json:"change_type"`
+	Direct     bool        `json:"direct,omitempty"`
+	Old        interface{} `json:"old,omitempty"`
+	New        interface{} `json:"new,omitempty"`
+	Children   []*DiffNode `json:"children,omitempty"`
+}
+
+//NewTree is used to build the diffs tree from the flat diffs.
+//Direct is set on the nodes which carry a diff, the other nodes only group their children and are marked as Changed.
+//The diffs of a field which already has a diff are kept by the sibling nodes of the same path, so Flatten returns all of them
+func NewTree(diffs []Diff) *DiffNode {
+	root := &DiffNode{ChangeType: Changed}
+	for _, d := range diffs {
+		parent, current := root, root.objectChild(d.ObjectType, d.ObjectID)
+		if d.Field != "" {
+			for _, segment := range SplitField(d.Field) {
+				parent, current = current, current.child(segment)
+			}
+		}
+
+		if current.Direct {
+			current = &DiffNode{
+				Name:       current.Name,
+				Path:       current.Path,
+				ObjectType: current.ObjectType,
+				ObjectID:   current.ObjectID,
+			}
+			parent.Children = append(parent.Children, current)
+		}
+
+		current.ChangeType = d.ChangeType
+		current.Direct = true
+		current.Old = d.Old
+		current.New = d.New
+	}
+
+	return root
+}
+
+//Flatten is used to convert the tree back into the flat diffs.
+//The diffs are ordered by object and then by field in the order of their first appearance
+func (n *DiffNode) Flatten() []Diff {
+	diffs := []Diff{}
+	n.Walk(func(node *DiffNode) bool {
+		if node.Direct {
+			diffs = append(diffs, Diff{
+				ChangeType: node.ChangeType,
+				ObjectType: node.ObjectType,
+				ObjectID:   node.ObjectID,
+				Field:      node.Path,
+				Old:        node.Old,
+				New:        node.New,
+			})
+		}
+		return true
+	})
+
+	return diffs
+}
+
+//Walk visits the node and its descendants in depth-first order. The children are skipped when fn returns false
+func (n *DiffNode) Walk(fn func(node *DiffNode) bool) {
+	if !fn(n) {
+		return
+	}
+
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+func (n *DiffNode) objectChild(objectType, objectID string) *DiffNode {
+	for _, c := range n.Children {
+		if c.ObjectType == objectType && c.ObjectID == objectID {
+			return c
+		}
+	}
+
+	c := &DiffNode{ObjectType: objectType, ObjectID: objectID, ChangeType: Changed}
+	n.Children = append(n.Children, c)
+
+	return c
+}
+
+func (n *DiffNode) child(name string) *DiffNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	path := JoinField(name)
+	if n.Path != "" {
+		path = n.Path + "." + JoinField(name)
+	}
+
+	c := &DiffNode{
+		Name:       name,
+		Path:       path,
+		ObjectType: n.ObjectType,
+		ObjectID:   n.ObjectID,
+		ChangeType: Changed,
+	}
+	n.Children = append(n.Children, c)
+
+	return c
+}
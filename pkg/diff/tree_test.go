@@ -0,0 +1,204 @@
+package diff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+func TestNewTree(t *testing.T) {
+	type args struct {
+		diffs []diff.Diff
+	}
+	tests := []struct {
+		name string
+		args args
+		want *diff.DiffNode
+	}{
+		{
+			"succeed when nest the diffs by object and field",
+			args{
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					Field:      "Address.Street",
+					Old:        "jalan 123",
+					New:        "jalan ABC",
+				}, {
+					ChangeType: diff.Removed,
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					Field:      "Address.City",
+					Old:        "Malang",
+				}, {
+					ChangeType: diff.New,
+					ObjectType: "diff_test.person",
+					ObjectID:   "2",
+					New:        person{ID: 2},
+				}},
+			},
+			&diff.DiffNode{
+				ChangeType: diff.Changed,
+				Children: []*diff.DiffNode{{
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					ChangeType: diff.Changed,
+					Children: []*diff.DiffNode{{
+						Name:       "Address",
+						Path:       "Address",
+						ObjectType: "diff_test.person",
+						ObjectID:   "1",
+						ChangeType: diff.Changed,
+						Children: []*diff.DiffNode{{
+							Name:       "Street",
+							Path:       "Address.Street",
+							ObjectType: "diff_test.person",
+							ObjectID:   "1",
+							ChangeType: diff.Changed,
+							Direct:     true,
+							Old:        "jalan 123",
+							New:        "jalan ABC",
+						}, {
+							Name:       "City",
+							Path:       "Address.City",
+							ObjectType: "diff_test.person",
+							ObjectID:   "1",
+							ChangeType: diff.Removed,
+							Direct:     true,
+							Old:        "Malang",
+						}},
+					}},
+				}, {
+					ObjectType: "diff_test.person",
+					ObjectID:   "2",
+					ChangeType: diff.New,
+					Direct:     true,
+					New:        person{ID: 2},
+				}},
+			},
+		}, {
+			"succeed when keep the diffs of the same field",
+			args{
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					Field:      "Age",
+					Old:        1,
+					New:        2,
+				}, {
+					ChangeType: diff.Changed,
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					Field:      "Age",
+					Old:        2,
+					New:        3,
+				}},
+			},
+			&diff.DiffNode{
+				ChangeType: diff.Changed,
+				Children: []*diff.DiffNode{{
+					ObjectType: "diff_test.person",
+					ObjectID:   "1",
+					ChangeType: diff.Changed,
+					Children: []*diff.DiffNode{{
+						Name:       "Age",
+						Path:       "Age",
+						ObjectType: "diff_test.person",
+						ObjectID:   "1",
+						ChangeType: diff.Changed,
+						Direct:     true,
+						Old:        1,
+						New:        2,
+					}, {
+						Name:       "Age",
+						Path:       "Age",
+						ObjectType: "diff_test.person",
+						ObjectID:   "1",
+						ChangeType: diff.Changed,
+						Direct:     true,
+						Old:        2,
+						New:        3,
+					}},
+				}},
+			},
+		}, {
+			"succeed when keep the escaped dots within the segment",
+			args{
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "map[string]interface {}",
+					Field:      `Labels.app\.name`,
+					Old:        "a",
+					New:        "b",
+				}},
+			},
+			&diff.DiffNode{
+				ChangeType: diff.Changed,
+				Children: []*diff.DiffNode{{
+					ObjectType: "map[string]interface {}",
+					ChangeType: diff.Changed,
+					Children: []*diff.DiffNode{{
+						Name:       "Labels",
+						Path:       "Labels",
+						ObjectType: "map[string]interface {}",
+						ChangeType: diff.Changed,
+						Children: []*diff.DiffNode{{
+							Name:       "app.name",
+							Path:       `Labels.app\.name`,
+							ObjectType: "map[string]interface {}",
+							ChangeType: diff.Changed,
+							Direct:     true,
+							Old:        "a",
+							New:        "b",
+						}},
+					}},
+				}},
+			},
+		}, {
+			"succeed when build the empty tree",
+			args{
+				diffs: []diff.Diff{},
+			},
+			&diff.DiffNode{
+				ChangeType: diff.Changed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diff.NewTree(tt.args.diffs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewTree() = %v, want %v", got, tt.want)
+			}
+			if flatten := got.Flatten(); !reflect.DeepEqual(flatten, tt.args.diffs) {
+				t.Errorf("DiffNode.Flatten() = %v, want %v", flatten, tt.args.diffs)
+			}
+		})
+	}
+}
+
+func TestDiffNode_Walk(t *testing.T) {
+	tree := diff.NewTree([]diff.Diff{{
+		ChangeType: diff.Changed,
+		ObjectType: "diff_test.person",
+		Field:      "Address.Street",
+	}, {
+		ChangeType: diff.Changed,
+		ObjectType: "diff_test.person",
+		Field:      "Name",
+	}})
+
+	paths := []string{}
+	tree.Walk(func(node *diff.DiffNode) bool {
+		paths = append(paths, node.Path)
+		return node.Path != "Address"
+	})
+
+	want := []string{"", "", "Address", "Name"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("DiffNode.Walk() = %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,226 @@
+package jsondoc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//object is the normalized JSON object
+type object map[string]interface{}
+
+//array is the normalized JSON array, which is keyed by the index so its elements are compared one by one
+type array map[string]interface{}
+
+//text is the JSON encoding of a scalar value, or of a value which type is different on the other document
+type text string
+
+//Decode reads a single JSON document into the generic values, i.e. map[string]interface{}, []interface{}, json.Number,
+//string, bool and nil. The numbers are kept as their decimal text, so the large integers do not lose their precision
+func Decode(r io.Reader) (interface{}, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("error on decode JSON Error : %s", err.Error())
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("error on decode JSON Error : unexpected data after the document")
+	}
+
+	return v, nil
+}
+
+//Compare is used to compare two JSON documents which are decoded into the generic values.
+//The array elements are compared by their index, and the diff values are given back as the generic values
+func Compare(ctx context.Context, old, new interface{}) ([]diff.Diff, error) {
+	oldVal, err := normalize(old)
+	if err != nil {
+		return nil, err
+	}
+
+	newVal, err := normalize(new)
+	if err != nil {
+		return nil, err
+	}
+
+	oldVal, newVal = align(oldVal, newVal)
+	diffs, err := libra.Compare(ctx, oldVal, newVal)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range diffs {
+		diffs[i].ObjectType = ""
+		diffs[i].ObjectID = ""
+		diffs[i].Old = denormalize(diffs[i].Old)
+		diffs[i].New = denormalize(diffs[i].New)
+	}
+
+	return diffs, nil
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := object{}
+		for k, elem := range value {
+			normalized, err := normalize(elem)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = normalized
+		}
+
+		return result, nil
+	case []interface{}:
+		result := array{}
+		for i, elem := range value {
+			normalized, err := normalize(elem)
+			if err != nil {
+				return nil, err
+			}
+			result[strconv.Itoa(i)] = normalized
+		}
+
+		return result, nil
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("error on encode JSON value Error : %s", err.Error())
+		}
+
+		if len(b) > 0 && (b[0] == '-' || b[0] >= '0' && b[0] <= '9') {
+			return text(CanonicalNumber(json.Number(b))), nil
+		}
+
+		return text(b), nil
+	}
+}
+
+//CanonicalNumber gives the exact decimal text of a JSON number without its insignificant zeros, so 1.0 is equal to 1
+//while 9007199254740993 is not equal to 9007199254740992. The number is kept as it is when its exponent is out of range
+func CanonicalNumber(n json.Number) string {
+	s, sign := string(n), ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return sign + s
+		}
+		mantissa, exponent = s[:i], e
+	}
+
+	digits := mantissa
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		exponent -= len(mantissa) - i - 1
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+
+	trimmed := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(trimmed)
+	digits = trimmed
+
+	switch {
+	case exponent >= 0 && len(digits)+exponent <= 21:
+		return sign + digits + strings.Repeat("0", exponent)
+	case exponent < 0 && -exponent < len(digits):
+		point := len(digits) + exponent
+		return sign + digits[:point] + "." + digits[point:]
+	case exponent < 0 && -exponent-len(digits) <= 6:
+		return sign + "0." + strings.Repeat("0", -exponent-len(digits)) + digits
+	default:
+		return sign + digits + "e" + strconv.Itoa(exponent)
+	}
+}
+
+//align converts the values which types are different into text, so they are compared as a single value
+func align(old, new interface{}) (interface{}, interface{}) {
+	if reflect.TypeOf(old) != reflect.TypeOf(new) {
+		return toText(old), toText(new)
+	}
+
+	switch oldValue := old.(type) {
+	case object:
+		newValue := new.(object)
+		for k := range oldValue {
+			if _, ok := newValue[k]; ok {
+				oldValue[k], newValue[k] = align(oldValue[k], newValue[k])
+			}
+		}
+	case array:
+		newValue := new.(array)
+		for k := range oldValue {
+			if _, ok := newValue[k]; ok {
+				oldValue[k], newValue[k] = align(oldValue[k], newValue[k])
+			}
+		}
+	}
+
+	return old, new
+}
+
+func toText(v interface{}) text {
+	if t, ok := v.(text); ok {
+		return t
+	}
+
+	b, _ := json.Marshal(denormalize(v))
+	return text(b)
+}
+
+//denormalize converts the normalized value back into the generic value
+func denormalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case object:
+		result := make(map[string]interface{}, len(value))
+		for k, elem := range value {
+			result[k] = denormalize(elem)
+		}
+
+		return result
+	case array:
+		keys := make([]int, 0, len(value))
+		for k := range value {
+			i, _ := strconv.Atoi(k)
+			keys = append(keys, i)
+		}
+		sort.Ints(keys)
+
+		result := make([]interface{}, 0, len(value))
+		for _, i := range keys {
+			result = append(result, denormalize(value[strconv.Itoa(i)]))
+		}
+
+		return result
+	case text:
+		decoder := json.NewDecoder(strings.NewReader(string(value)))
+		decoder.UseNumber()
+
+		var result interface{}
+		decoder.Decode(&result)
+
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,147 @@
+package jsondoc_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsondoc"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{"succeed when decode the object", `{"a": [1, "b", null]}`, map[string]interface{}{"a": []interface{}{json.Number("1"), "b", nil}}, false},
+		{"succeed when decode the scalar", ` true `, true, false},
+		{"failed when decode the invalid document", `{"a": `, nil, true},
+		{"failed when decode multiple documents", `{} {}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsondoc.Decode(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when the documents are equal regardless of the formatting",
+			`{"a": 1, "b": {"c": [1, 2]}}`,
+			`{"b":{"c":[1,2.0]},"a":1}`,
+			[]diff.Diff{},
+			false,
+		}, {
+			"succeed when the large integers are changed",
+			`{"id": 9007199254740993, "ratio": 1.50, "size": 1e3}`,
+			`{"id": 9007199254740992, "ratio": 1.5, "size": 1000}`,
+			[]diff.Diff{{ChangeType: diff.Changed, Field: "id", Old: json.Number("9007199254740993"), New: json.Number("9007199254740992")}},
+			false,
+		}, {
+			"succeed when the values are changed, added and removed",
+			`{"name": "app", "port": 80, "debug": true, "server": {"host": "a"}}`,
+			`{"name": "web", "port": 80, "server": {"host": "b", "tls": null}}`,
+			[]diff.Diff{
+				{ChangeType: diff.Removed, Field: "debug", Old: true},
+				{ChangeType: diff.Changed, Field: "name", Old: "app", New: "web"},
+				{ChangeType: diff.Changed, Field: "server.host", Old: "a", New: "b"},
+				{ChangeType: diff.New, Field: "server.tls", New: nil},
+			},
+			false,
+		}, {
+			"succeed when the array elements are changed",
+			`{"tags": ["a", "b", "c"], "items": [{"id": 1}]}`,
+			`{"tags": ["a", "x"], "items": [{"id": 2}, {"id": 3}]}`,
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "items.0.id", Old: json.Number("1"), New: json.Number("2")},
+				{ChangeType: diff.New, Field: "items.1", New: map[string]interface{}{"id": json.Number("3")}},
+				{ChangeType: diff.Changed, Field: "tags.1", Old: "b", New: "x"},
+				{ChangeType: diff.Removed, Field: "tags.2", Old: "c"},
+			},
+			false,
+		}, {
+			"succeed when the value types are different",
+			`{"a": "1", "b": {"c": 1}, "d": null}`,
+			`{"a": 1, "b": [1], "d": {}}`,
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "a", Old: "1", New: json.Number("1")},
+				{ChangeType: diff.Changed, Field: "b", Old: map[string]interface{}{"c": json.Number("1")}, New: []interface{}{json.Number("1")}},
+				{ChangeType: diff.Changed, Field: "d", Old: nil, New: map[string]interface{}{}},
+			},
+			false,
+		}, {
+			"succeed when the root types are different",
+			`[1]`,
+			`"a"`,
+			[]diff.Diff{{ChangeType: diff.Changed, Old: []interface{}{json.Number("1")}, New: "a"}},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := jsondoc.Decode(strings.NewReader(tt.old))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			new, err := jsondoc.Decode(strings.NewReader(tt.new))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			got, err := jsondoc.Compare(context.Background(), old, new)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		number json.Number
+		want   string
+	}{
+		{"0", "0"},
+		{"-0.0", "0"},
+		{"9007199254740993", "9007199254740993"},
+		{"1.50", "1.5"},
+		{"-001.0", "-1"},
+		{"1e3", "1000"},
+		{"1.5E+2", "150"},
+		{"0.000120", "0.00012"},
+		{"0.0000000012", "12e-10"},
+		{"1e25", "1e25"},
+		{"1e99999999999999999999", "1e99999999999999999999"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.number), func(t *testing.T) {
+			if got := jsondoc.CanonicalNumber(tt.number); got != tt.want {
+				t.Errorf("CanonicalNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
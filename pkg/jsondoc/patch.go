@@ -0,0 +1,208 @@
+package jsondoc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+//Patch is used to apply the diffs onto a copy of the JSON document which is decoded into the generic values.
+//All of the diffs are checked before any of them is applied, and *patcher.ConflictError is returned when a new value
+//already exists or the current value is not equal to the old value of a diff
+func Patch(ctx context.Context, doc interface{}, diffs []diff.Diff) (interface{}, error) {
+	conflicts := []patcher.Conflict{}
+	for _, d := range diffs {
+		current, found := Lookup(doc, d.Field)
+		conflict := patcher.Conflict{
+			ObjectType: d.ObjectType,
+			ObjectID:   d.ObjectID,
+			Field:      d.Field,
+			Actual:     current,
+		}
+
+		switch d.ChangeType {
+		case diff.New:
+			if found {
+				conflicts = append(conflicts, conflict)
+			}
+		default:
+			if !found || !equal(current, d.Old) {
+				conflict.Expected = d.Old
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &patcher.ConflictError{Conflicts: conflicts}
+	}
+
+	result := clone(doc)
+	for _, d := range patcher.Order(diffs) {
+		updated, err := apply(result, patcher.SplitField(d.Field), d)
+		if err != nil {
+			return nil, fmt.Errorf("error on patch field %s Error : %s", d.Field, err.Error())
+		}
+		result = updated
+	}
+
+	return result, nil
+}
+
+//DecodeJSONPatch converts the JSON Patch operations into the diffs which could be applied onto the document.
+//The old values of the replace and remove operations without a test operation are taken from the document,
+//so only the existence of their paths is checked by Patch
+func DecodeJSONPatch(doc interface{}, ops []codec.JSONPatchOperation) ([]diff.Diff, error) {
+	tested := make([]codec.JSONPatchOperation, 0, len(ops))
+	for i, op := range ops {
+		hasTest := i > 0 && ops[i-1].Op == "test" && ops[i-1].Path == op.Path
+		if !hasTest && (op.Op == "replace" || op.Op == "remove") {
+			if field, err := codec.ParseJSONPointer(op.Path); err == nil {
+				if current, ok := Lookup(doc, field); ok {
+					tested = append(tested, codec.JSONPatchOperation{Op: "test", Path: op.Path, Value: current})
+				}
+			}
+		}
+		tested = append(tested, op)
+	}
+
+	return codec.DecodeJSONPatch(tested)
+}
+
+//Lookup finds the value of the field within the JSON document, the array elements are found by their index
+func Lookup(doc interface{}, field string) (interface{}, bool) {
+	for _, segment := range patcher.SplitField(field) {
+		switch value := doc.(type) {
+		case map[string]interface{}:
+			elem, ok := value[segment]
+			if !ok {
+				return nil, false
+			}
+			doc = elem
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(value) {
+				return nil, false
+			}
+			doc = value[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
+
+func apply(v interface{}, path []string, d diff.Diff) (interface{}, error) {
+	if len(path) == 0 {
+		if d.ChangeType == diff.Removed {
+			return nil, nil
+		}
+
+		return clone(d.New), nil
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			if d.ChangeType == diff.Removed {
+				delete(value, path[0])
+			} else {
+				value[path[0]] = clone(d.New)
+			}
+
+			return value, nil
+		}
+
+		elem, ok := value[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("key %s is not found", path[0])
+		}
+
+		updated, err := apply(elem, path[1:], d)
+		if err != nil {
+			return nil, err
+		}
+		value[path[0]] = updated
+
+		return value, nil
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("invalid index %s", path[0])
+		}
+
+		if len(path) == 1 {
+			switch {
+			case d.ChangeType == diff.New && i == len(value):
+				return append(value, clone(d.New)), nil
+			case d.ChangeType == diff.Removed && i < len(value):
+				return append(value[:i:i], value[i+1:]...), nil
+			}
+		}
+
+		if i >= len(value) {
+			return nil, fmt.Errorf("index %d is out of range", i)
+		}
+
+		updated, err := apply(value[i], path[1:], d)
+		if err != nil {
+			return nil, err
+		}
+		value[i] = updated
+
+		return value, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse %s on %T", path[0], v)
+	}
+}
+
+//equal compares the normalized JSON encodings, so the numbers are equal by their exact decimal values
+func equal(a, b interface{}) bool {
+	normalizedA, errA := normalizeJSON(a)
+	normalizedB, errB := normalizeJSON(b)
+
+	return errA == nil && errB == nil && reflect.DeepEqual(normalizedA, normalizedB)
+}
+
+func normalizeJSON(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize(decoded)
+}
+
+func clone(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, elem := range value {
+			result[k] = clone(elem)
+		}
+
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, elem := range value {
+			result[i] = clone(elem)
+		}
+
+		return result
+	default:
+		return v
+	}
+}
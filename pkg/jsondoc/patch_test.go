@@ -0,0 +1,228 @@
+package jsondoc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsondoc"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+
+	v, err := jsondoc.Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	return v
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		doc           string
+		diffs         []diff.Diff
+		want          string
+		wantConflicts []string
+		wantErr       bool
+	}{
+		{
+			"succeed when apply the changes",
+			`{"name": "app", "debug": false, "tags": ["a", "b", "c"], "server": {"port": 80}}`,
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "debug", Old: false, New: true},
+				{ChangeType: diff.Removed, Field: "tags.1", Old: "b"},
+				{ChangeType: diff.Removed, Field: "tags.2", Old: "c"},
+				{ChangeType: diff.Changed, Field: "server.port", Old: 80, New: json.Number("443")},
+				{ChangeType: diff.New, Field: "server.tls", New: map[string]interface{}{"cert": "a.pem"}},
+			},
+			`{"name": "app", "debug": true, "tags": ["a"], "server": {"port": 443, "tls": {"cert": "a.pem"}}}`,
+			nil,
+			false,
+		}, {
+			"succeed when append the array elements",
+			`{"tags": ["a"]}`,
+			[]diff.Diff{
+				{ChangeType: diff.New, Field: "tags.2", New: "c"},
+				{ChangeType: diff.New, Field: "tags.1", New: "b"},
+			},
+			`{"tags": ["a", "b", "c"]}`,
+			nil,
+			false,
+		}, {
+			"succeed when replace the whole document",
+			`[1]`,
+			[]diff.Diff{{ChangeType: diff.Changed, Old: []interface{}{1.0}, New: "a"}},
+			`"a"`,
+			nil,
+			false,
+		}, {
+			"failed when the old values are conflicted",
+			`{"name": "web", "port": 80}`,
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "name", Old: "app", New: "api"},
+				{ChangeType: diff.Removed, Field: "tls", Old: true},
+				{ChangeType: diff.New, Field: "port", New: 81.0},
+				{ChangeType: diff.Changed, Field: "port", Old: 80.0, New: 81.0},
+			},
+			"",
+			[]string{"name", "tls", "port"},
+			true,
+		}, {
+			"failed when the field could not be traversed",
+			`{"name": "app"}`,
+			[]diff.Diff{{ChangeType: diff.New, Field: "name.first", New: "a"}},
+			"",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			original := decode(t, tt.doc)
+
+			got, err := jsondoc.Patch(context.Background(), doc, tt.diffs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Patch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(doc, original) {
+				t.Errorf("Patch() modified the document into %v", doc)
+			}
+
+			if tt.wantErr {
+				conflictErr := &patcher.ConflictError{}
+				if tt.wantConflicts != nil && !errors.As(err, &conflictErr) {
+					t.Fatalf("Patch() error = %v, want *patcher.ConflictError", err)
+				}
+
+				fields := []string{}
+				for _, c := range conflictErr.Conflicts {
+					fields = append(fields, c.Field)
+				}
+				if tt.wantConflicts != nil && !reflect.DeepEqual(fields, tt.wantConflicts) {
+					t.Errorf("Patch() conflicts = %v, want %v", fields, tt.wantConflicts)
+				}
+				return
+			}
+
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Patch() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPatch_Compare(t *testing.T) {
+	old := decode(t, `{"a": [1, {"b": null}], "c": {"d": "x"}, "e": 0, "f": [1, 2, 3]}`)
+	new := decode(t, `{"a": [1, {"b": false}, 2], "c": [true], "e": "", "f": [1]}`)
+
+	diffs, err := jsondoc.Compare(context.Background(), old, new)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	got, err := jsondoc.Patch(context.Background(), old, diffs)
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, new) {
+		t.Errorf("Patch() = %v, want %v", got, new)
+	}
+}
+
+func TestPatch_DottedKeys(t *testing.T) {
+	old := decode(t, `{"a.b": 1, "a": {"b": 1}}`)
+	new := decode(t, `{"a.b": 2, "a": {"b": 1}}`)
+
+	diffs, err := jsondoc.Compare(context.Background(), old, new)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	want := []diff.Diff{{ChangeType: diff.Changed, Field: `a\.b`, Old: json.Number("1"), New: json.Number("2")}}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Compare() = %v, want %v", diffs, want)
+	}
+
+	ops := codec.EncodeJSONPatch(diffs, false)
+	if len(ops) != 1 || ops[0].Path != "/a.b" {
+		t.Errorf("EncodeJSONPatch() = %v, want the path /a.b", ops)
+	}
+
+	decoded, err := jsondoc.DecodeJSONPatch(old, ops)
+	if err != nil {
+		t.Fatalf("DecodeJSONPatch() error = %v", err)
+	}
+	for _, diffs := range [][]diff.Diff{diffs, decoded} {
+		got, err := jsondoc.Patch(context.Background(), old, diffs)
+		if err != nil {
+			t.Fatalf("Patch() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, new) {
+			t.Errorf("Patch() = %v, want %v", got, new)
+		}
+	}
+}
+
+func TestDecodeJSONPatch(t *testing.T) {
+	doc := decode(t, `{"name": "app", "tags": ["a", "b"]}`)
+	tests := []struct {
+		name    string
+		ops     []codec.JSONPatchOperation
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when take the old values from the document",
+			[]codec.JSONPatchOperation{
+				{Op: "replace", Path: "/name", Value: "web"},
+				{Op: "remove", Path: "/tags/1"},
+				{Op: "add", Path: "/port", Value: 80.0},
+			},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "name", Old: "app", New: "web"},
+				{ChangeType: diff.Removed, Field: "tags.1", Old: "b"},
+				{ChangeType: diff.New, Field: "port", New: 80.0},
+			},
+			false,
+		}, {
+			"succeed when keep the old values of the test operations",
+			[]codec.JSONPatchOperation{
+				{Op: "test", Path: "/name", Value: "api"},
+				{Op: "replace", Path: "/name", Value: "web"},
+				{Op: "remove", Path: "/tags/5"},
+			},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: "name", Old: "api", New: "web"},
+				{ChangeType: diff.Removed, Field: "tags.5"},
+			},
+			false,
+		}, {
+			"failed when the operation is unsupported",
+			[]codec.JSONPatchOperation{{Op: "copy", Path: "/a"}},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsondoc.DecodeJSONPatch(doc, tt.ops)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DecodeJSONPatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeJSONPatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsondoc"
+)
+
+//defaultBufferSize is the size of the records which are sorted in memory before they are spilled into a temporary file
+const defaultBufferSize = 32 << 20
+
+//Options is the options of comparing two JSON Lines datasets
+type Options struct {
+	//Key is the dotted path of the record key, e.g. id or customer.id. The key value should be a string, a number or a boolean,
+	//and the records are identified by its JSON encoding
+	Key string
+
+	//BufferSize is the maximum size in bytes of the records which are sorted in memory, the larger datasets are sorted
+	//in the temporary files. Zero uses the default of 32 MiB
+	BufferSize int
+
+	//TempDir is the directory of the temporary files, the default is os.TempDir
+	TempDir string
+}
+
+func (o Options) bufferSize() int {
+	if o.BufferSize <= 0 {
+		return defaultBufferSize
+	}
+
+	return o.BufferSize
+}
+
+//Compare is used to compare two JSON Lines datasets which records are matched by the key. The datasets are sorted by
+//the key with bounded memory, and fn is called with the diffs of each record which is different in the order of the keys.
+//The diffs of a record are identified by the JSON encoding of its key, e.g. 1 or "a", the whole record is given when it is added or removed
+func Compare(ctx context.Context, old, new io.Reader, opts Options, fn func([]diff.Diff) error) error {
+	if opts.Key == "" {
+		return fmt.Errorf("error on compare JSON Lines Error : key is required")
+	}
+
+	oldSet, err := sortDataset(ctx, old, opts)
+	if err != nil {
+		return fmt.Errorf("error on sort old dataset Error : %s", err.Error())
+	}
+	defer oldSet.close()
+
+	newSet, err := sortDataset(ctx, new, opts)
+	if err != nil {
+		return fmt.Errorf("error on sort new dataset Error : %s", err.Error())
+	}
+	defer newSet.close()
+
+	o, hasOld, err := oldSet.next()
+	if err != nil {
+		return fmt.Errorf("error on read old dataset Error : %s", err.Error())
+	}
+
+	n, hasNew, err := newSet.next()
+	if err != nil {
+		return fmt.Errorf("error on read new dataset Error : %s", err.Error())
+	}
+
+	for hasOld || hasNew {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var diffs []diff.Diff
+		advanceOld, advanceNew := false, false
+		switch {
+		case hasOld && (!hasNew || o.key < n.key):
+			value, err := jsondoc.Decode(bytes.NewReader(o.data))
+			if err != nil {
+				return err
+			}
+			diffs = []diff.Diff{{ChangeType: diff.Removed, ObjectID: o.key, Old: value}}
+			advanceOld = true
+		case hasNew && (!hasOld || n.key < o.key):
+			value, err := jsondoc.Decode(bytes.NewReader(n.data))
+			if err != nil {
+				return err
+			}
+			diffs = []diff.Diff{{ChangeType: diff.New, ObjectID: n.key, New: value}}
+			advanceNew = true
+		default:
+			if diffs, err = compareRecords(ctx, o, n); err != nil {
+				return err
+			}
+			advanceOld, advanceNew = true, true
+		}
+
+		if len(diffs) > 0 {
+			if err := fn(diffs); err != nil {
+				return err
+			}
+		}
+
+		if advanceOld {
+			if o, hasOld, err = oldSet.next(); err != nil {
+				return fmt.Errorf("error on read old dataset Error : %s", err.Error())
+			}
+		}
+
+		if advanceNew {
+			if n, hasNew, err = newSet.next(); err != nil {
+				return fmt.Errorf("error on read new dataset Error : %s", err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func compareRecords(ctx context.Context, old, new record) ([]diff.Diff, error) {
+	if bytes.Equal(old.data, new.data) {
+		return nil, nil
+	}
+
+	oldValue, err := jsondoc.Decode(bytes.NewReader(old.data))
+	if err != nil {
+		return nil, err
+	}
+
+	newValue, err := jsondoc.Decode(bytes.NewReader(new.data))
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := jsondoc.Compare(ctx, oldValue, newValue)
+	if err != nil {
+		return nil, fmt.Errorf("error on compare record %s Error : %s", new.key, err.Error())
+	}
+
+	for i := range diffs {
+		diffs[i].ObjectID = new.key
+	}
+
+	return diffs, nil
+}
+
+//sortDataset reads the records of a dataset and sorts them by key
+func sortDataset(ctx context.Context, r io.Reader, opts Options) (*dataset, error) {
+	s := &sorter{opts: opts}
+	path := strings.Split(opts.Key, ".")
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			s.close()
+			return nil, err
+		}
+
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			s.close()
+			return nil, readErr
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 {
+			rec, err := newRecord(line, data, path)
+			if err != nil {
+				s.close()
+				return nil, err
+			}
+
+			if err := s.add(rec); err != nil {
+				s.close()
+				return nil, err
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	it, err := s.iterator()
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+
+	return &dataset{iterator: it, sorter: s}, nil
+}
+
+func newRecord(line int, data []byte, path []string) (record, error) {
+	value, err := jsondoc.Decode(bytes.NewReader(data))
+	if err != nil {
+		return record{}, fmt.Errorf("line %d: %s", line, err.Error())
+	}
+
+	key, err := lookupKey(value, path)
+	if err != nil {
+		return record{}, fmt.Errorf("line %d: %s", line, err.Error())
+	}
+
+	return record{key: key, line: line, data: append([]byte(nil), data...)}, nil
+}
+
+//lookupKey finds the key on the path of a record. The key is given as its JSON encoding, so the string "1" and
+//the number 1 are different keys, and the numbers are given as their canonical text so 1.0 is the same key as 1
+func lookupKey(value interface{}, path []string) (string, error) {
+	for _, segment := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			elem, ok := v[segment]
+			if !ok {
+				return "", fmt.Errorf("key %s is not found", strings.Join(path, "."))
+			}
+			value = elem
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("key %s is not found", strings.Join(path, "."))
+			}
+			value = v[i]
+		default:
+			return "", fmt.Errorf("key %s is not found", strings.Join(path, "."))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		b := &bytes.Buffer{}
+		encoder := json.NewEncoder(b)
+		encoder.SetEscapeHTML(false)
+		err := encoder.Encode(v)
+		return strings.TrimSuffix(b.String(), "\n"), err
+	case json.Number:
+		return jsondoc.CanonicalNumber(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("key %s should be a string, a number or a boolean", strings.Join(path, "."))
+	}
+}
+
+//dataset is the sorted records of a dataset, the duplicate keys are rejected while they are read
+type dataset struct {
+	iterator iterator
+	sorter   *sorter
+	last     *record
+}
+
+func (d *dataset) next() (record, bool, error) {
+	rec, ok, err := d.iterator.next()
+	if err != nil || !ok {
+		return rec, ok, err
+	}
+
+	if d.last != nil && d.last.key == rec.key {
+		first, second := d.last.line, rec.line
+		if first > second {
+			first, second = second, first
+		}
+
+		return record{}, false, fmt.Errorf("duplicate key %s on line %d and %d", rec.key, first, second)
+	}
+	d.last = &rec
+
+	return rec, true, nil
+}
+
+func (d *dataset) close() error {
+	return d.sorter.close()
+}
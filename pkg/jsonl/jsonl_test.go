@@ -0,0 +1,190 @@
+package jsonl_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsonl"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		opts    jsonl.Options
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when the records are added, removed and changed",
+			`{"id": 3, "name": "c"}
+{"id": 1, "name": "a", "tags": ["x"]}
+
+{"id": 2, "name": "b"}`,
+			`{"name": "a", "tags": ["x"], "id": 1}
+{"id": 4, "name": "d"}
+{"id": 3, "name": "C"}
+`,
+			jsonl.Options{Key: "id"},
+			[]diff.Diff{
+				{ChangeType: diff.Removed, ObjectID: "2", Old: map[string]interface{}{"id": json.Number("2"), "name": "b"}},
+				{ChangeType: diff.Changed, ObjectID: "3", Field: "name", Old: "c", New: "C"},
+				{ChangeType: diff.New, ObjectID: "4", New: map[string]interface{}{"id": json.Number("4"), "name": "d"}},
+			},
+			false,
+		}, {
+			"succeed when match the records by the nested key",
+			`{"customer": {"id": "b"}, "total": 1}
+{"customer": {"id": "a"}, "total": 2}`,
+			`{"customer": {"id": "a"}, "total": 3}
+{"customer": {"id": "b"}, "total": 1}`,
+			jsonl.Options{Key: "customer.id"},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectID: `"a"`, Field: "total", Old: json.Number("2"), New: json.Number("3")},
+			},
+			false,
+		}, {
+			"succeed when match the records by the type of the key",
+			`{"id": "1", "name": "a"}
+{"id": 1, "name": "b"}`,
+			`{"id": 1.0, "name": "B"}
+{"id": "1", "name": "A"}`,
+			jsonl.Options{Key: "id"},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectID: `"1"`, Field: "name", Old: "a", New: "A"},
+				{ChangeType: diff.Changed, ObjectID: "1", Field: "name", Old: "b", New: "B"},
+			},
+			false,
+		}, {
+			"succeed when the large integers are changed",
+			`{"id": 9007199254740993, "total": 9007199254740993}
+{"id": 9007199254740992, "total": 1}`,
+			`{"id": 9007199254740992, "total": 1.0}
+{"id": 9007199254740993, "total": 9007199254740992}`,
+			jsonl.Options{Key: "id"},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, ObjectID: "9007199254740993", Field: "total", Old: json.Number("9007199254740993"), New: json.Number("9007199254740992")},
+			},
+			false,
+		}, {
+			"succeed when the datasets are equal",
+			`{"id": "a"}`,
+			`{"id":"a"}`,
+			jsonl.Options{Key: "id"},
+			[]diff.Diff{},
+			false,
+		}, {
+			"failed when the key is not found",
+			`{"id": 1}`,
+			`{"name": "a"}`,
+			jsonl.Options{Key: "id"},
+			nil,
+			true,
+		}, {
+			"failed when the key is an object",
+			`{"id": {"a": 1}}`,
+			`{"id": 1}`,
+			jsonl.Options{Key: "id"},
+			nil,
+			true,
+		}, {
+			"failed when the key is duplicated",
+			`{"id": 1}
+{"id": 1}`,
+			`{"id": 1}`,
+			jsonl.Options{Key: "id"},
+			nil,
+			true,
+		}, {
+			"failed when the line is invalid",
+			`{"id": 1}`,
+			`{"id": 1`,
+			jsonl.Options{Key: "id"},
+			nil,
+			true,
+		}, {
+			"failed when the key is empty",
+			`{"id": 1}`,
+			`{"id": 1}`,
+			jsonl.Options{},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []diff.Diff{}
+			err := jsonl.Compare(context.Background(), strings.NewReader(tt.old), strings.NewReader(tt.new), tt.opts, func(diffs []diff.Diff) error {
+				got = append(got, diffs...)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_Spill(t *testing.T) {
+	old, new := &strings.Builder{}, &strings.Builder{}
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(old, "{\"id\": \"%04d\", \"value\": %d}\n", (i*7)%500, (i*7)%500)
+	}
+	for i := 499; i >= 1; i-- {
+		value := i
+		if i%100 == 0 {
+			value = -i
+		}
+		fmt.Fprintf(new, "{\"id\": \"%04d\", \"value\": %d}\n", i, value)
+	}
+	fmt.Fprintf(new, "{\"id\": \"%04d\", \"value\": %d}\n", 500, 500)
+
+	dir := t.TempDir()
+	got := []string{}
+	err := jsonl.Compare(context.Background(), strings.NewReader(old.String()), strings.NewReader(new.String()), jsonl.Options{Key: "id", BufferSize: 1024, TempDir: dir}, func(diffs []diff.Diff) error {
+		for _, d := range diffs {
+			got = append(got, fmt.Sprintf("%s %s", d.ChangeType, d.ObjectID))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	want := []string{`removed "0000"`, `changed "0100"`, `changed "0200"`, `changed "0300"`, `changed "0400"`, `new "0500"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %v, want %v", got, want)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Compare() left %d temporary files", len(entries))
+	}
+}
+
+func TestCompare_Callback(t *testing.T) {
+	errStop := errors.New("stop")
+	calls := 0
+	err := jsonl.Compare(context.Background(), strings.NewReader(`{"id": 1}`), strings.NewReader(`{"id": 2}`), jsonl.Options{Key: "id"}, func(diffs []diff.Diff) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Compare() error = %v, calls = %d, want %v and 1 call", err, calls, errStop)
+	}
+}
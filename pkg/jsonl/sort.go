@@ -0,0 +1,224 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+//recordOverhead is the approximate memory of a record besides its key and data
+const recordOverhead = 64
+
+//record is a line of a dataset with its key, which is the JSON encoding of the key value
+type record struct {
+	key  string
+	line int
+	data []byte
+}
+
+//iterator gives the records in the order of the keys
+type iterator interface {
+	next() (record, bool, error)
+}
+
+//sorter sorts the records of a dataset by key. The records are buffered in memory and the sorted runs
+//are spilled into the temporary files when the buffer is full, which are merged by the iterator
+type sorter struct {
+	opts    Options
+	records []record
+	size    int
+	runs    []*os.File
+}
+
+func (s *sorter) add(r record) error {
+	s.records = append(s.records, r)
+	s.size += len(r.key) + len(r.data) + recordOverhead
+	if s.size >= s.opts.bufferSize() {
+		return s.spill()
+	}
+
+	return nil
+}
+
+func (s *sorter) sortRecords() {
+	sort.SliceStable(s.records, func(i, j int) bool {
+		return s.records[i].key < s.records[j].key
+	})
+}
+
+//spill writes the sorted records into a temporary file, each of them is written as the quoted key,
+//the line number and the data which are separated by tabs
+func (s *sorter) spill() error {
+	s.sortRecords()
+
+	f, err := os.CreateTemp(s.opts.TempDir, "libra-jsonl-*")
+	if err != nil {
+		return fmt.Errorf("error on create temporary file Error : %s", err.Error())
+	}
+	s.runs = append(s.runs, f)
+
+	w := bufio.NewWriter(f)
+	for _, r := range s.records {
+		key, err := json.Marshal(r.key)
+		if err != nil {
+			return err
+		}
+
+		w.Write(key)
+		w.WriteByte('\t')
+		w.WriteString(strconv.Itoa(r.line))
+		w.WriteByte('\t')
+		w.Write(r.data)
+		w.WriteByte('\n')
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("error on write temporary file Error : %s", err.Error())
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("error on read temporary file Error : %s", err.Error())
+	}
+
+	s.records = nil
+	s.size = 0
+
+	return nil
+}
+
+//iterator merges the spilled runs and the buffered records
+func (s *sorter) iterator() (iterator, error) {
+	s.sortRecords()
+	if len(s.runs) == 0 {
+		return &sliceIterator{records: s.records}, nil
+	}
+
+	m := &mergeIterator{}
+	sources := []iterator{&sliceIterator{records: s.records}}
+	for _, f := range s.runs {
+		sources = append(sources, &runIterator{reader: bufio.NewReader(f)})
+	}
+
+	for _, source := range sources {
+		r, ok, err := source.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			m.heads = append(m.heads, mergeHead{record: r, source: source})
+		}
+	}
+	heap.Init(m)
+
+	return m, nil
+}
+
+//close removes the temporary files
+func (s *sorter) close() error {
+	var result error
+	for _, f := range s.runs {
+		f.Close()
+		if err := os.Remove(f.Name()); err != nil && result == nil {
+			result = err
+		}
+	}
+	s.runs = nil
+
+	return result
+}
+
+type sliceIterator struct {
+	records []record
+}
+
+func (it *sliceIterator) next() (record, bool, error) {
+	if len(it.records) == 0 {
+		return record{}, false, nil
+	}
+
+	r := it.records[0]
+	it.records = it.records[1:]
+
+	return r, true, nil
+}
+
+//runIterator reads the records of a spilled run
+type runIterator struct {
+	reader *bufio.Reader
+}
+
+func (it *runIterator) next() (record, bool, error) {
+	line, err := it.reader.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		return record{}, false, nil
+	}
+	if err != nil && err != io.EOF {
+		return record{}, false, fmt.Errorf("error on read temporary file Error : %s", err.Error())
+	}
+
+	fields := bytes.SplitN(bytes.TrimSuffix(line, []byte("\n")), []byte("\t"), 3)
+	if len(fields) != 3 {
+		return record{}, false, fmt.Errorf("error on read temporary file Error : invalid record")
+	}
+
+	r := record{data: fields[2]}
+	if err := json.Unmarshal(fields[0], &r.key); err != nil {
+		return record{}, false, fmt.Errorf("error on read temporary file Error : %s", err.Error())
+	}
+
+	if r.line, err = strconv.Atoi(string(fields[1])); err != nil {
+		return record{}, false, fmt.Errorf("error on read temporary file Error : %s", err.Error())
+	}
+
+	return r, true, nil
+}
+
+type mergeHead struct {
+	record record
+	source iterator
+}
+
+//mergeIterator merges the sorted iterators with a heap of their next records
+type mergeIterator struct {
+	heads []mergeHead
+}
+
+func (m *mergeIterator) Len() int           { return len(m.heads) }
+func (m *mergeIterator) Less(i, j int) bool { return m.heads[i].record.key < m.heads[j].record.key }
+func (m *mergeIterator) Swap(i, j int)      { m.heads[i], m.heads[j] = m.heads[j], m.heads[i] }
+func (m *mergeIterator) Push(x interface{}) { m.heads = append(m.heads, x.(mergeHead)) }
+
+func (m *mergeIterator) Pop() interface{} {
+	last := m.heads[len(m.heads)-1]
+	m.heads = m.heads[:len(m.heads)-1]
+
+	return last
+}
+
+func (m *mergeIterator) next() (record, bool, error) {
+	if len(m.heads) == 0 {
+		return record{}, false, nil
+	}
+
+	head := m.heads[0]
+	r, ok, err := head.source.next()
+	if err != nil {
+		return record{}, false, err
+	}
+
+	if ok {
+		m.heads[0].record = r
+		heap.Fix(m, 0)
+	} else {
+		heap.Pop(m)
+	}
+
+	return head.record, true, nil
+}
@@ -0,0 +1,309 @@
+package lint
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//tagValues are the valid values of the libra tag
+var tagValues = []string{"id", "ignore"}
+
+//basicTypes are the predeclared types which could be the ID of an object
+var basicTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true, "uintptr": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+//maxDepth limits the resolution of the named types and the nested structs
+const maxDepth = 16
+
+//Issue is a misuse of the libra tag
+type Issue struct {
+	Pos     token.Position
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+//Dir parses the Go files of the directory and checks them by package, the build constraints are not considered
+func Dir(ctx context.Context, dir string) ([]Issue, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error on read directory %s Error : %s", dir, err.Error())
+	}
+
+	fset := token.NewFileSet()
+	packages := map[string][]*ast.File{}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("error on parse %s Error : %s", filepath.Join(dir, entry.Name()), err.Error())
+		}
+
+		if _, ok := packages[file.Name.Name]; !ok {
+			names = append(names, file.Name.Name)
+		}
+		packages[file.Name.Name] = append(packages[file.Name.Name], file)
+	}
+
+	issues := []Issue{}
+	for _, name := range names {
+		issues = append(issues, Check(fset, packages[name])...)
+	}
+	sortIssues(issues)
+
+	return issues, nil
+}
+
+//Check reports the misuses of the libra tag within the files of a package, which are the invalid tag values,
+//the duplicate IDs, the tags on the unexported fields and the ID fields of the unsupported kinds. The ID should be
+//a boolean, a number or a string, because the fields of the struct kind are searched for the ID instead
+func Check(fset *token.FileSet, files []*ast.File) []Issue {
+	c := &checker{fset: fset, types: map[string]ast.Expr{}, issues: []Issue{}}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				c.types[typeSpec.Name.Name] = typeSpec.Type
+			}
+		}
+	}
+
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if s, ok := n.(*ast.StructType); ok {
+				c.checkStruct(s)
+			}
+
+			return true
+		})
+	}
+	sortIssues(c.issues)
+
+	return c.issues
+}
+
+type checker struct {
+	fset   *token.FileSet
+	types  map[string]ast.Expr
+	issues []Issue
+}
+
+func (c *checker) report(pos token.Pos, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{Pos: c.fset.Position(pos), Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) checkStruct(s *ast.StructType) {
+	first := token.NoPos
+	for _, field := range s.Fields.List {
+		value, tagged := tag(field)
+		names := fieldNames(field)
+		if tagged {
+			if !validTag(value) {
+				c.report(field.Tag.Pos(), "invalid libra tag value %q, expected %s", value, strings.Join(tagValues, " or "))
+			}
+
+			for _, name := range names {
+				if !ast.IsExported(name.Name) {
+					c.report(name.Pos(), "libra tag on unexported field %s", name.Name)
+				}
+			}
+
+			if value == "id" {
+				if kind := c.kind(field.Type); kind != "" && kind != "basic" {
+					c.report(field.Type.Pos(), "ID field %s has unsupported kind %s, expected a boolean, a number or a string", names[0].Name, kind)
+				}
+			}
+		}
+
+		for _, name := range names {
+			pos := token.NoPos
+			if tagged && value == "id" && c.kind(field.Type) != "struct" {
+				pos = name.Pos()
+			} else if nested := c.structType(field.Type); nested != nil {
+				pos = c.firstID(nested, 0)
+			}
+
+			if !pos.IsValid() {
+				continue
+			}
+
+			if first.IsValid() {
+				c.report(name.Pos(), "duplicate ID on field %s, the ID is already defined at %s", name.Name, c.fset.Position(first))
+				continue
+			}
+			first = pos
+		}
+	}
+}
+
+//firstID finds the first ID field of the struct the same way as diff.GetObjectID, which searches the nested structs
+func (c *checker) firstID(s *ast.StructType, depth int) token.Pos {
+	if depth > maxDepth {
+		return token.NoPos
+	}
+
+	for _, field := range s.Fields.List {
+		if value, tagged := tag(field); tagged && value == "id" && c.kind(field.Type) != "struct" {
+			return fieldNames(field)[0].Pos()
+		}
+
+		if nested := c.structType(field.Type); nested != nil {
+			if pos := c.firstID(nested, depth+1); pos.IsValid() {
+				return pos
+			}
+		}
+	}
+
+	return token.NoPos
+}
+
+//underlying resolves the named types which are declared within the package,
+//nil is returned when the type is declared elsewhere
+func (c *checker) underlying(expr ast.Expr) ast.Expr {
+	for depth := 0; depth < maxDepth; depth++ {
+		switch t := expr.(type) {
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.Ident:
+			if basicTypes[t.Name] || t.Name == "any" || t.Name == "error" {
+				return t
+			}
+
+			declared, ok := c.types[t.Name]
+			if !ok {
+				return nil
+			}
+			expr = declared
+		default:
+			return expr
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) structType(expr ast.Expr) *ast.StructType {
+	s, _ := c.underlying(expr).(*ast.StructType)
+	return s
+}
+
+//kind gives the kind of the type, which is empty when it is unknown
+func (c *checker) kind(expr ast.Expr) string {
+	switch t := c.underlying(expr).(type) {
+	case *ast.Ident:
+		if basicTypes[t.Name] {
+			return "basic"
+		}
+		return "interface"
+	case *ast.StructType:
+		return "struct"
+	case *ast.StarExpr:
+		return "pointer"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "slice"
+		}
+		return "array"
+	case *ast.MapType:
+		return "map"
+	case *ast.FuncType:
+		return "func"
+	case *ast.ChanType:
+		return "chan"
+	case *ast.InterfaceType:
+		return "interface"
+	default:
+		return ""
+	}
+}
+
+//tag returns the value of the libra tag of the field
+func tag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+
+	return reflect.StructTag(raw).Lookup("libra")
+}
+
+func validTag(value string) bool {
+	for _, v := range tagValues {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+//fieldNames returns the names of the field, the embedded field is named by its type
+func fieldNames(field *ast.Field) []*ast.Ident {
+	if len(field.Names) > 0 {
+		return field.Names
+	}
+
+	expr := field.Type
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.SelectorExpr:
+			return []*ast.Ident{t.Sel}
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.Ident:
+			return []*ast.Ident{t}
+		default:
+			return []*ast.Ident{{NamePos: field.Type.Pos(), Name: "?"}}
+		}
+	}
+}
+
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i].Pos, issues[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+}
@@ -0,0 +1,168 @@
+package lint_test
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/lint"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			"succeed when the tags are valid",
+			`package a
+
+type ID string
+
+type Order struct {
+	ID     ID       ` + "`libra:\"id\"`" + `
+	Note   string   ` + "`json:\"note\" libra:\"ignore\"`" + `
+	Items  []string
+	secret string
+}
+
+type Payment struct {
+	Amount money.Amount ` + "`libra:\"id\"`" + `
+}`,
+			[]string{},
+		},
+		{
+			"succeed when the tag value is invalid",
+			`package a
+
+type Order struct {
+	Note  string ` + "`libra:\"igonre\"`" + `
+	Empty string ` + "`libra:\"\"`" + `
+}`,
+			[]string{
+				`a.go:4:15: invalid libra tag value "igonre", expected id or ignore`,
+				`a.go:5:15: invalid libra tag value "", expected id or ignore`,
+			},
+		},
+		{
+			"succeed when the tag is on an unexported field",
+			`package a
+
+type Order struct {
+	id, Name string ` + "`libra:\"ignore\"`" + `
+	*base    ` + "`libra:\"ignore\"`" + `
+}
+
+type base struct{}`,
+			[]string{
+				"a.go:4:2: libra tag on unexported field id",
+				"a.go:5:3: libra tag on unexported field base",
+			},
+		},
+		{
+			"succeed when the ID is duplicated",
+			`package a
+
+type Base struct {
+	Key int ` + "`libra:\"id\"`" + `
+}
+
+type Order struct {
+	Base
+	Number, Code int ` + "`libra:\"id\"`" + `
+	Inner struct {
+		Ref string ` + "`libra:\"id\"`" + `
+	}
+}`,
+			[]string{
+				"a.go:9:2: duplicate ID on field Number, the ID is already defined at a.go:4:2",
+				"a.go:9:10: duplicate ID on field Code, the ID is already defined at a.go:4:2",
+				"a.go:10:2: duplicate ID on field Inner, the ID is already defined at a.go:4:2",
+			},
+		},
+		{
+			"succeed when the ID has an unsupported kind",
+			`package a
+
+type Tags []string
+
+type Order struct {
+	Base  Base           ` + "`libra:\"id\"`" + `
+}
+
+type Base struct {
+	Tags  Tags           ` + "`libra:\"id\"`" + `
+}
+
+type Item struct {
+	Ref   *int           ` + "`libra:\"id\"`" + `
+	Attrs map[string]int ` + "`libra:\"id\"`" + `
+	Value interface{}    ` + "`libra:\"id\"`" + `
+}`,
+			[]string{
+				"a.go:6:8: ID field Base has unsupported kind struct, expected a boolean, a number or a string",
+				"a.go:10:8: ID field Tags has unsupported kind slice, expected a boolean, a number or a string",
+				"a.go:14:8: ID field Ref has unsupported kind pointer, expected a boolean, a number or a string",
+				"a.go:15:2: duplicate ID on field Attrs, the ID is already defined at a.go:14:2",
+				"a.go:15:8: ID field Attrs has unsupported kind map, expected a boolean, a number or a string",
+				"a.go:16:2: duplicate ID on field Value, the ID is already defined at a.go:14:2",
+				"a.go:16:8: ID field Value has unsupported kind interface, expected a boolean, a number or a string",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", tt.src, 0)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+
+			got := []string{}
+			for _, issue := range lint.Check(fset, []*ast.File{file}) {
+				got = append(got, issue.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"order.go":      "package a\n\ntype Order struct {\n\tID string `libra:\"id\"`\n}\n",
+		"order_test.go": "package a_test\n\ntype Order struct {\n\tID int `libra:\"id\"`\n\tNo int `libra:\"id\"`\n}\n",
+		"README.md":     "not a Go file",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := lint.Dir(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "order_test.go") + ":5:2: duplicate ID on field No, the ID is already defined at " +
+		filepath.Join(dir, "order_test.go") + ":4:2"}
+	if len(got) != len(want) || got[0].String() != want[0] {
+		t.Errorf("Dir() = %v, want %v", got, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.go"), []byte("package a\n\ntype"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lint.Dir(context.Background(), dir); err == nil {
+		t.Errorf("Dir() error = %v, wantErr %v", err, true)
+	}
+}
@@ -0,0 +1,144 @@
+package patcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//Compose returns the diffs which have the same effect as applying the diff sets in order,
+//e.g. the diffs of the consecutive commits of an object are composed into the diffs of a single commit
+func Compose(ctx context.Context, sets ...[]diff.Diff) ([]diff.Diff, error) {
+	result := []diff.Diff{}
+	for _, set := range sets {
+		for _, d := range set {
+			var err error
+			if result, err = compose(ctx, result, d); err != nil {
+				return nil, fmt.Errorf("error on compose field %s Error : %s", d.Field, err.Error())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func compose(ctx context.Context, result []diff.Diff, d diff.Diff) ([]diff.Diff, error) {
+	for i, r := range result {
+		if r.Field == d.Field {
+			combined, ok := combine(r, d)
+			if !ok {
+				return append(result[:i], result[i+1:]...), nil
+			}
+
+			result[i] = combined
+			return result, nil
+		}
+	}
+
+	//The parent is changed before, so the change is applied onto its new value
+	for i, r := range result {
+		if !isParentField(r.Field, d.Field) {
+			continue
+		}
+
+		if r.ChangeType == diff.Removed {
+			return nil, fmt.Errorf("parent %s is removed", r.Field)
+		}
+
+		updated, err := patchValue(ctx, r.New, trimField(d.Field, r.Field), d)
+		if err != nil {
+			return nil, err
+		}
+		result[i].New = updated
+
+		return result, nil
+	}
+
+	//The children are changed before, so their old values are restored onto the old value of the parent
+	children := []diff.Diff{}
+	rest := []diff.Diff{}
+	for _, r := range result {
+		if isParentField(d.Field, r.Field) {
+			children = append(children, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+
+	if len(children) > 0 {
+		old := d.Old
+		for i := len(children) - 1; i >= 0; i-- {
+			var err error
+			if old, err = patchValue(ctx, old, trimField(children[i].Field, d.Field), diff.InvertDiff(children[i])); err != nil {
+				return nil, err
+			}
+		}
+
+		d.Old = old
+		if d.ChangeType == diff.New && old != nil {
+			d.ChangeType = diff.Changed
+		}
+	}
+
+	return append(rest, d), nil
+}
+
+//combine returns the diff of two consecutive changes on the same field, it returns false when they cancel each other
+func combine(r, d diff.Diff) (diff.Diff, bool) {
+	combined := d
+	combined.Old = r.Old
+
+	switch {
+	case r.ChangeType == diff.New && d.ChangeType == diff.Removed:
+		return diff.Diff{}, false
+	case r.ChangeType == diff.New:
+		combined.ChangeType = diff.New
+		combined.Old = nil
+		return combined, true
+	case d.ChangeType == diff.Removed:
+		combined.ChangeType = diff.Removed
+		combined.New = nil
+		return combined, true
+	default:
+		combined.ChangeType = diff.Changed
+		return combined, !reflect.DeepEqual(combined.Old, combined.New)
+	}
+}
+
+//patchValue applies the diff onto a copy of the value, the field of the diff is relative to the value
+func patchValue(ctx context.Context, value interface{}, field string, d diff.Diff) (interface{}, error) {
+	if value == nil {
+		return nil, fmt.Errorf("cannot patch %s on nil value", field)
+	}
+
+	v := reflect.ValueOf(value)
+	target := reflect.New(v.Type()).Elem()
+	target.Set(Clone(v))
+
+	d.ObjectType = ""
+	d.Field = field
+	if err := Apply(ctx, target, []diff.Diff{d}); err != nil {
+		return nil, err
+	}
+
+	return target.Interface(), nil
+}
+
+func isParentField(parent, child string) bool {
+	if parent == "" {
+		return child != ""
+	}
+
+	return strings.HasPrefix(child, parent+".")
+}
+
+func trimField(field, parent string) string {
+	if parent == "" {
+		return field
+	}
+
+	return strings.TrimPrefix(field, parent+".")
+}
@@ -0,0 +1,128 @@
+package patcher_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+func TestCompose(t *testing.T) {
+	changed := func(field string, old, new interface{}) diff.Diff {
+		return diff.Diff{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: field, Old: old, New: new}
+	}
+
+	tests := []struct {
+		name    string
+		sets    [][]diff.Diff
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when compose the changes of different fields",
+			[][]diff.Diff{{changed("Name", "a", "b")}, {changed("Age", 1, 2)}},
+			[]diff.Diff{changed("Name", "a", "b"), changed("Age", 1, 2)},
+			false,
+		}, {
+			"succeed when compose the changes of the same field",
+			[][]diff.Diff{{changed("Name", "a", "b")}, {changed("Name", "b", "c")}},
+			[]diff.Diff{changed("Name", "a", "c")},
+			false,
+		}, {
+			"succeed when the changes cancel each other",
+			[][]diff.Diff{{changed("Name", "a", "b"), changed("Age", 1, 2)}, {changed("Name", "b", "a")}},
+			[]diff.Diff{changed("Age", 1, 2)},
+			false,
+		}, {
+			"succeed when the new field is removed",
+			[][]diff.Diff{
+				{{ChangeType: diff.New, Field: "Interface", New: "x"}},
+				{{ChangeType: diff.Removed, Field: "Interface", Old: "x"}},
+			},
+			[]diff.Diff{},
+			false,
+		}, {
+			"succeed when the changed field is removed",
+			[][]diff.Diff{
+				{changed("Interface", "x", "y")},
+				{{ChangeType: diff.Removed, Field: "Interface", Old: "y"}},
+			},
+			[]diff.Diff{{ChangeType: diff.Removed, Field: "Interface", Old: "x"}},
+			false,
+		}, {
+			"succeed when the new object is changed",
+			[][]diff.Diff{
+				{{ChangeType: diff.New, ObjectType: "patcher_test.person", ObjectID: "1", New: person{ID: 1, Name: "a"}}},
+				{changed("Name", "a", "b"), changed("Address.City", "", "Malang")},
+			},
+			[]diff.Diff{{ChangeType: diff.New, ObjectType: "patcher_test.person", ObjectID: "1",
+				New: person{ID: 1, Name: "b", Address: address{City: "Malang"}}}},
+			false,
+		}, {
+			"succeed when the parent of the changed fields is changed",
+			[][]diff.Diff{
+				{changed("Address.City", "Jakarta", "Malang")},
+				{changed("Address", address{Street: "jalan", City: "Malang"}, address{City: "Bandung"})},
+			},
+			[]diff.Diff{changed("Address", address{Street: "jalan", City: "Jakarta"}, address{City: "Bandung"})},
+			false,
+		}, {
+			"failed when the field of the removed parent is changed",
+			[][]diff.Diff{
+				{{ChangeType: diff.Removed, Field: "Address", Old: address{City: "Malang"}}},
+				{changed("Address.City", "Malang", "Bandung")},
+			},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patcher.Compose(context.Background(), tt.sets...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compose() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compose() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompose_Apply(t *testing.T) {
+	versions := []person{
+		{ID: 1, Name: "a", Age: 10, Numbers: []int{1}},
+		{ID: 1, Name: "b", Age: 10, Numbers: []int{1, 2}, Address: address{City: "Malang"}},
+		{ID: 1, Name: "a", Age: 11, Numbers: []int{2}, Address: address{Street: "jalan", City: "Malang"}},
+	}
+
+	sets := [][]diff.Diff{
+		{
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Name", Old: "a", New: "b"},
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Numbers", Old: "1", New: "1,2"},
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Address.City", Old: "", New: "Malang"},
+		},
+		{
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Name", Old: "b", New: "a"},
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Age", Old: 10, New: 11},
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Numbers", Old: "1,2", New: "2"},
+			{ChangeType: diff.Changed, ObjectType: "patcher_test.person", ObjectID: "1", Field: "Address.Street", Old: "", New: "jalan"},
+		},
+	}
+
+	composed, err := patcher.Compose(context.Background(), sets...)
+	if err != nil {
+		t.Fatalf("Compose() error = %v", err)
+	}
+
+	target := versions[0]
+	if err := patcher.Apply(context.Background(), reflect.ValueOf(&target).Elem(), composed); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(target, versions[2]) {
+		t.Errorf("Apply() = %+v, want %+v", target, versions[2])
+	}
+}
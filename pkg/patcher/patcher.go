@@ -0,0 +1,460 @@
+package patcher
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//Conflict represents a diff which could not be applied since the current value is not equal to the expected one
+type Conflict struct {
+	ObjectType string
+	ObjectID   string
+	Field      string
+	Expected   interface{}
+	Actual     interface{}
+}
+
+//ConflictError is returned when one or more diffs are conflicted with the target
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	fields := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		fields = append(fields, fmt.Sprintf("%s (expected '%v' got '%v')", c.Field, c.Expected, c.Actual))
+	}
+
+	return fmt.Sprintf("conflict on patch: %s", strings.Join(fields, ", "))
+}
+
+//Apply is used to apply the diffs onto the target value.
+//All of the diffs are checked against the target before any of them is applied, and they are applied onto a clone
+//of the target which is only written back when all of them succeed, so the target is left untouched on any error.
+func Apply(ctx context.Context, target reflect.Value, diffs []diff.Diff) error {
+	objectType := indirectType(target.Type())
+	conflicts := []Conflict{}
+	for _, d := range diffs {
+		if d.ObjectType != "" && d.ObjectType != target.Type().String() && d.ObjectType != objectType.String() {
+			return fmt.Errorf("different object type %s Error : expected %s", d.ObjectType, objectType.String())
+		}
+
+		conflict, err := check(ctx, target, d)
+		if err != nil {
+			return fmt.Errorf("error on check field %s Error : %s", d.Field, err.Error())
+		}
+
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	patched := Clone(target)
+	for _, d := range Order(diffs) {
+		updated, err := apply(ctx, patched, SplitField(d.Field), d)
+		if err != nil {
+			return fmt.Errorf("error on patch field %s Error : %s", d.Field, err.Error())
+		}
+		patched = updated
+	}
+
+	assign(target, patched)
+
+	return nil
+}
+
+//assign writes the patched value back into the target, the target which could not be set is written through
+//its pointer or its map entries
+func assign(target, patched reflect.Value) {
+	switch {
+	case target.CanSet():
+		target.Set(patched)
+	case target.Kind() == reflect.Ptr && !target.IsNil() && !patched.IsNil():
+		target.Elem().Set(patched.Elem())
+	case target.Kind() == reflect.Map && !target.IsNil():
+		iter := target.MapRange()
+		for iter.Next() {
+			if !patched.MapIndex(iter.Key()).IsValid() {
+				target.SetMapIndex(iter.Key(), reflect.Value{})
+			}
+		}
+
+		iter = patched.MapRange()
+		for iter.Next() {
+			target.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+}
+
+//SplitField is used to split the field of a diff into the path segments, the escaped dots and backslashes are unescaped
+//within the segments, e.g. `a\.b.c` gives `a.b` and `c`
+func SplitField(field string) []string {
+	return diff.SplitField(field)
+}
+
+//Lookup is used to find the value on the given path. It returns an invalid value when the path does not exist
+func Lookup(ctx context.Context, v reflect.Value, path []string) (reflect.Value, error) {
+	for i, segment := range path {
+		v = indirect(v)
+		switch v.Kind() {
+		case reflect.Invalid, reflect.Ptr, reflect.Interface:
+			return reflect.Value{}, nil
+		case reflect.Struct:
+			field, err := fieldByName(v, segment)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v = field
+		case reflect.Map:
+			key, err := convertValue(ctx, v.Type().Key(), segment)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid key %s Error : %s", segment, err.Error())
+			}
+			v = v.MapIndex(key)
+		case reflect.Slice, reflect.Array:
+			index, err := parseIndex(segment)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if index >= v.Len() {
+				return reflect.Value{}, nil
+			}
+			v = v.Index(index)
+		default:
+			return reflect.Value{}, fmt.Errorf("cannot traverse %s on %s", diff.JoinField(path[:i+1]...), v.Kind())
+		}
+	}
+
+	return v, nil
+}
+
+func check(ctx context.Context, target reflect.Value, d diff.Diff) (*Conflict, error) {
+	current, err := Lookup(ctx, target, SplitField(d.Field))
+	if err != nil {
+		return nil, err
+	}
+
+	conflict := &Conflict{
+		ObjectType: d.ObjectType,
+		ObjectID:   d.ObjectID,
+		Field:      d.Field,
+		Actual:     normalize(current),
+	}
+
+	switch d.ChangeType {
+	case diff.New:
+		if current.IsValid() && !current.IsZero() {
+			return conflict, nil
+		}
+	default:
+		if !equal(current, d.Old) {
+			conflict.Expected = d.Old
+			return conflict, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func apply(ctx context.Context, v reflect.Value, path []string, d diff.Diff) (reflect.Value, error) {
+	if len(path) == 0 {
+		if d.ChangeType == diff.Removed {
+			return reflect.Zero(v.Type()), nil
+		}
+
+		return convertValue(ctx, v.Type(), d.New)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if d.ChangeType == diff.Removed {
+				return v, nil
+			}
+			v = reflect.New(v.Type().Elem())
+		}
+
+		updated, err := apply(ctx, v.Elem(), path, d)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.Elem().Set(updated)
+
+		return v, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("cannot traverse %s on nil value", path[0])
+		}
+
+		return apply(ctx, v.Elem(), path, d)
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+
+		field, err := fieldByName(copied, path[0])
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		updated, err := apply(ctx, field, path[1:], d)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		field.Set(updated)
+
+		return copied, nil
+	case reflect.Map:
+		return applyMap(ctx, v, path, d)
+	case reflect.Slice, reflect.Array:
+		return applySlice(ctx, v, path, d)
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot traverse %s on %s", path[0], v.Kind())
+	}
+}
+
+func applyMap(ctx context.Context, v reflect.Value, path []string, d diff.Diff) (reflect.Value, error) {
+	key, err := convertValue(ctx, v.Type().Key(), path[0])
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid key %s Error : %s", path[0], err.Error())
+	}
+
+	if v.IsNil() {
+		if d.ChangeType == diff.Removed {
+			return v, nil
+		}
+		v = reflect.MakeMap(v.Type())
+	}
+
+	if len(path) == 1 {
+		if d.ChangeType == diff.Removed {
+			v.SetMapIndex(key, reflect.Value{})
+			return v, nil
+		}
+
+		value, err := convertValue(ctx, v.Type().Elem(), d.New)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v.SetMapIndex(key, value)
+
+		return v, nil
+	}
+
+	elem := v.MapIndex(key)
+	if !elem.IsValid() {
+		return reflect.Value{}, fmt.Errorf("key %s is not found", path[0])
+	}
+
+	updated, err := apply(ctx, elem, path[1:], d)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v.SetMapIndex(key, updated)
+
+	return v, nil
+}
+
+func applySlice(ctx context.Context, v reflect.Value, path []string, d diff.Diff) (reflect.Value, error) {
+	index, err := parseIndex(path[0])
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	if v.Kind() == reflect.Array {
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		v = copied
+	}
+
+	if len(path) == 1 && v.Kind() == reflect.Slice {
+		if d.ChangeType == diff.New && index == v.Len() {
+			value, err := convertValue(ctx, v.Type().Elem(), d.New)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			return reflect.Append(v, value), nil
+		}
+
+		if d.ChangeType == diff.Removed && index < v.Len() {
+			removed := reflect.MakeSlice(v.Type(), 0, v.Len()-1)
+			removed = reflect.AppendSlice(removed, v.Slice(0, index))
+
+			return reflect.AppendSlice(removed, v.Slice(index+1, v.Len())), nil
+		}
+	}
+
+	if index >= v.Len() {
+		return reflect.Value{}, fmt.Errorf("index %d is out of range", index)
+	}
+
+	updated, err := apply(ctx, v.Index(index), path[1:], d)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	v.Index(index).Set(updated)
+
+	return v, nil
+}
+
+//Order keeps the changed diffs on its position, then appends the new diffs in ascending order
+//and the removed diffs in descending order, so the slice indexes stay valid while they are applied
+func Order(diffs []diff.Diff) []diff.Diff {
+	changed := []diff.Diff{}
+	added := []diff.Diff{}
+	removed := []diff.Diff{}
+	for _, d := range diffs {
+		switch d.ChangeType {
+		case diff.New:
+			added = append(added, d)
+		case diff.Removed:
+			removed = append(removed, d)
+		default:
+			changed = append(changed, d)
+		}
+	}
+
+	sort.SliceStable(added, func(i, j int) bool {
+		return compareField(added[i].Field, added[j].Field) < 0
+	})
+	sort.SliceStable(removed, func(i, j int) bool {
+		return compareField(removed[i].Field, removed[j].Field) > 0
+	})
+
+	return append(append(changed, added...), removed...)
+}
+
+//compareField compares two fields segment by segment, the numeric segments are compared by their value
+func compareField(a, b string) int {
+	as := SplitField(a)
+	bs := SplitField(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			if ai < bi {
+				return -1
+			}
+			return 1
+		}
+
+		return strings.Compare(as[i], bs[i])
+	}
+
+	return len(as) - len(bs)
+}
+
+func fieldByName(v reflect.Value, name string) (reflect.Value, error) {
+	typeField, ok := v.Type().FieldByName(name)
+	if !ok || !typeField.IsExported() {
+		return reflect.Value{}, fmt.Errorf("field %s is not found", name)
+	}
+
+	return v.FieldByIndexErr(typeField.Index)
+}
+
+func parseIndex(segment string) (int, error) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid index %s", segment)
+	}
+
+	return index, nil
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+//Clone returns a deep copy of the value, so the copy could be patched without touching the original value.
+//The unexported fields are copied as they are
+func Clone(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(Clone(v.Elem()))
+
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(Clone(v.Elem()))
+
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(Clone(v.Field(i)))
+			}
+		}
+
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), Clone(iter.Value()))
+		}
+
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(Clone(v.Index(i)))
+		}
+
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(Clone(v.Index(i)))
+		}
+
+		return copied
+	default:
+		return v
+	}
+}
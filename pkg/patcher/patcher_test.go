@@ -0,0 +1,327 @@
+package patcher_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+type person struct {
+	ID          int `libra:"id"`
+	Name        string
+	Age         int
+	Numbers     []int
+	Interface   interface{}
+	Address     address
+	Pointer     *address
+	DateOfBirth time.Time
+}
+
+type address struct {
+	Street string
+	City   string
+}
+
+func TestApply(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		target interface{}
+		diffs  []diff.Diff
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			"succeed when patch the struct fields",
+			args{
+				ctx: nil,
+				target: &person{
+					ID:          10,
+					Name:        "test1",
+					Numbers:     []int{1, 2, 3},
+					DateOfBirth: time.Date(2020, time.May, 4, 0, 0, 0, 0, time.UTC),
+				},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "patcher_test.person",
+					ObjectID:   "10",
+					Field:      "Name",
+					Old:        "test1",
+					New:        "test2",
+				}, {
+					ChangeType: diff.Changed,
+					ObjectType: "patcher_test.person",
+					ObjectID:   "10",
+					Field:      "Numbers",
+					Old:        "1,2,3",
+					New:        "1,2,4",
+				}, {
+					ChangeType: diff.Changed,
+					ObjectType: "patcher_test.person",
+					ObjectID:   "10",
+					Field:      "DateOfBirth",
+					Old:        "2020-05-04 00:00:00 +0000 UTC",
+					New:        "2020-05-30 00:00:00 +0000 UTC",
+				}},
+			},
+			&person{
+				ID:          10,
+				Name:        "test2",
+				Numbers:     []int{1, 2, 4},
+				DateOfBirth: time.Date(2020, time.May, 30, 0, 0, 0, 0, time.UTC),
+			},
+			false,
+		}, {
+			"succeed when patch the nested fields",
+			args{
+				ctx: nil,
+				target: &person{
+					ID:      10,
+					Address: address{Street: "jalan 123"},
+				},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					Field:      "Address.Street",
+					Old:        "jalan 123",
+					New:        "jalan ABC",
+				}, {
+					ChangeType: diff.Changed,
+					Field:      "Pointer.City",
+					Old:        "",
+					New:        "Malang",
+				}},
+			},
+			&person{
+				ID:      10,
+				Address: address{Street: "jalan ABC"},
+				Pointer: &address{City: "Malang"},
+			},
+			false,
+		}, {
+			"succeed when patch the slice elements",
+			args{
+				ctx: nil,
+				target: &person{
+					Numbers: []int{1, 2, 3},
+				},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Removed,
+					Field:      "Numbers.1",
+					Old:        2,
+				}, {
+					ChangeType: diff.Changed,
+					Field:      "Numbers.0",
+					Old:        1,
+					New:        10,
+				}, {
+					ChangeType: diff.Removed,
+					Field:      "Numbers.2",
+					Old:        3,
+				}},
+			},
+			&person{
+				Numbers: []int{10},
+			},
+			false,
+		}, {
+			"succeed when patch the map keys",
+			args{
+				ctx: nil,
+				target: &map[string]interface{}{
+					"Age":    22,
+					"Weight": 80,
+					"Person": person{Name: "Rima"},
+				},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "map[string]interface {}",
+					Field:      "Age",
+					Old:        22,
+					New:        23,
+				}, {
+					ChangeType: diff.Removed,
+					Field:      "Weight",
+					Old:        80,
+				}, {
+					ChangeType: diff.New,
+					Field:      "Height",
+					New:        170,
+				}, {
+					ChangeType: diff.Changed,
+					Field:      "Person.Name",
+					Old:        "Rima",
+					New:        "Reza",
+				}},
+			},
+			&map[string]interface{}{
+				"Age":    23,
+				"Height": 170,
+				"Person": person{Name: "Reza"},
+			},
+			false,
+		}, {
+			"succeed when patch the serialized values",
+			args{
+				ctx: nil,
+				target: &person{
+					Age:     22,
+					Numbers: []int{1},
+				},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					Field:      "Age",
+					Old:        float64(22),
+					New:        float64(23),
+				}, {
+					ChangeType: diff.Changed,
+					Field:      "Numbers",
+					Old:        "1",
+					New:        []interface{}{float64(1), float64(2)},
+				}},
+			},
+			&person{
+				Age:     23,
+				Numbers: []int{1, 2},
+			},
+			false,
+		}, {
+			"succeed when patch the whole object",
+			args{
+				ctx:    nil,
+				target: &person{},
+				diffs: []diff.Diff{{
+					ChangeType: diff.New,
+					ObjectType: "patcher_test.person",
+					ObjectID:   "1",
+					New:        person{ID: 1, Name: "test1"},
+				}},
+			},
+			&person{ID: 1, Name: "test1"},
+			false,
+		}, {
+			"failed when the old value is not matched",
+			args{
+				ctx: nil,
+				target: &person{
+					Name: "test3",
+					Age:  22,
+				},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					Field:      "Age",
+					Old:        22,
+					New:        23,
+				}, {
+					ChangeType: diff.Changed,
+					Field:      "Name",
+					Old:        "test1",
+					New:        "test2",
+				}},
+			},
+			&person{
+				Name: "test3",
+				Age:  22,
+			},
+			true,
+		}, {
+			"failed when the field is not found",
+			args{
+				ctx:    nil,
+				target: &person{},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					Field:      "Unknown",
+					Old:        "test1",
+					New:        "test2",
+				}},
+			},
+			&person{},
+			true,
+		}, {
+			"failed when the object type is different",
+			args{
+				ctx:    nil,
+				target: &person{},
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "patcher_test.address",
+					Field:      "City",
+					Old:        "",
+					New:        "Malang",
+				}},
+			},
+			&person{},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := patcher.Apply(tt.args.ctx, reflect.ValueOf(tt.args.target).Elem(), tt.args.diffs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(tt.args.target, tt.want) {
+				t.Errorf("Apply() = %v, want %v", tt.args.target, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	target := person{Name: "test3"}
+	err := patcher.Apply(nil, reflect.ValueOf(&target).Elem(), []diff.Diff{{
+		ChangeType: diff.Changed,
+		Field:      "Name",
+		Old:        "test1",
+		New:        "test2",
+	}})
+
+	conflictErr, ok := err.(*patcher.ConflictError)
+	if !ok {
+		t.Fatalf("Apply() error = %v, want *ConflictError", err)
+	}
+
+	want := []patcher.Conflict{{
+		Field:    "Name",
+		Expected: "test1",
+		Actual:   "test3",
+	}}
+	if !reflect.DeepEqual(conflictErr.Conflicts, want) {
+		t.Errorf("ConflictError.Conflicts = %v, want %v", conflictErr.Conflicts, want)
+	}
+}
+
+func TestClone(t *testing.T) {
+	original := map[string]interface{}{
+		"person":  &person{Name: "test1", Numbers: []int{1, 2}},
+		"numbers": [2][]int{{1}, {2}},
+		"nested":  map[string][]string{"a": {"b"}},
+	}
+
+	cloned := patcher.Clone(reflect.ValueOf(original)).Interface().(map[string]interface{})
+	if !reflect.DeepEqual(cloned, original) {
+		t.Fatalf("Clone() = %v, want %v", cloned, original)
+	}
+
+	cloned["person"].(*person).Name = "test2"
+	cloned["person"].(*person).Numbers[0] = 10
+	cloned["numbers"].([2][]int)[0][0] = 10
+	cloned["nested"].(map[string][]string)["a"][0] = "c"
+
+	want := map[string]interface{}{
+		"person":  &person{Name: "test1", Numbers: []int{1, 2}},
+		"numbers": [2][]int{{1}, {2}},
+		"nested":  map[string][]string{"a": {"b"}},
+	}
+	if !reflect.DeepEqual(original, want) {
+		t.Errorf("Clone() shares the values with the original %v", original)
+	}
+}
@@ -0,0 +1,220 @@
+package patcher
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//timeStringLayout is the layout of time.Time.String which is used by the comparator to generate the diff
+const timeStringLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+func convertValue(ctx context.Context, t reflect.Type, value interface{}) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	if s, ok := value.(string); ok {
+		return parseValue(ctx, t, s)
+	}
+
+	if isNumberKind(v.Kind()) && isNumberKind(t.Kind()) {
+		return v.Convert(t), nil
+	}
+
+	if v.Kind() == t.Kind() && v.Type().ConvertibleTo(t) {
+		return v.Convert(t), nil
+	}
+
+	if t.Kind() == reflect.Slice && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+		result := reflect.MakeSlice(t, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := convertValue(ctx, t.Elem(), v.Index(i).Interface())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result = reflect.Append(result, elem)
+		}
+
+		return result, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot assign %T to %s", value, t.String())
+}
+
+func parseValue(ctx context.Context, t reflect.Type, s string) (reflect.Value, error) {
+	if t == timeType {
+		return parseTime(s)
+	}
+
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		ptr := reflect.New(t)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return reflect.Value{}, err
+		}
+
+		return ptr.Elem(), nil
+	}
+
+	result := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		result.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result.SetFloat(f)
+	case reflect.Slice:
+		//The comparator flattens the slices into comma separated string
+		result = reflect.MakeSlice(t, 0, 0)
+		if s == "" {
+			return result, nil
+		}
+		for _, part := range strings.Split(s, ",") {
+			elem, err := parseValue(ctx, t.Elem(), part)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result = reflect.Append(result, elem)
+		}
+	case reflect.Interface:
+		if !reflect.TypeOf(s).Implements(t) {
+			return reflect.Value{}, fmt.Errorf("cannot assign string to %s", t.String())
+		}
+		return reflect.ValueOf(s), nil
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot assign string to %s", t.String())
+	}
+
+	return result, nil
+}
+
+func parseTime(s string) (reflect.Value, error) {
+	//Strip the monotonic clock reading which is printed by time.Time.String
+	if i := strings.Index(s, " m="); i >= 0 {
+		s = s[:i]
+	}
+
+	if t, err := time.Parse(timeStringLayout, s); err == nil {
+		return reflect.ValueOf(t), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(t), nil
+}
+
+//normalize converts the value into the same representation which is used by the comparator
+func normalize(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	if v.Kind() == reflect.Array || v.Kind() == reflect.Slice {
+		parts := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts = append(parts, fmt.Sprintf("%v", v.Index(i).Interface()))
+		}
+
+		return strings.Join(parts, ",")
+	}
+
+	return v.Interface()
+}
+
+//equal reports whether the current value is equal to the expected value of a diff.
+//It tolerates the representation changes which happen when the diffs are serialized, e.g. int into float64 or string.
+func equal(current reflect.Value, expected interface{}) bool {
+	if !current.IsValid() || (current.Kind() == reflect.Interface && current.IsNil()) {
+		return expected == nil || reflect.ValueOf(expected).IsZero()
+	}
+
+	if current.CanInterface() && reflect.DeepEqual(current.Interface(), expected) {
+		return true
+	}
+
+	normalized := normalize(current)
+	if reflect.DeepEqual(normalized, expected) {
+		return true
+	}
+
+	if expected == nil || normalized == nil {
+		return false
+	}
+
+	nv := reflect.ValueOf(normalized)
+	ev := reflect.ValueOf(expected)
+	if isNumberKind(nv.Kind()) && isNumberKind(ev.Kind()) {
+		return nv.Convert(ev.Type()).Interface() == ev.Interface() &&
+			ev.Convert(nv.Type()).Interface() == nv.Interface()
+	}
+
+	if s, ok := expected.(string); ok {
+		return fmt.Sprintf("%v", normalized) == s
+	}
+
+	return false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package render
+
+import (
+	"context"
+	"html/template"
+	"io"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//HTMLReport is the data which is passed into the HTML template
+type HTMLReport struct {
+	Title       string
+	ObjectTypes []HTMLObjectType
+}
+
+//HTMLObjectType groups the changed objects which have the same ObjectType
+type HTMLObjectType struct {
+	Name    string
+	Objects []HTMLObject
+}
+
+//HTMLObject represents the changes of a single object
+type HTMLObject struct {
+	ID         string
+	ChangeType diff.ChangeType
+	Changes    []*HTMLChange
+}
+
+//HTMLChange represents a node of the changed paths. Old and New are only set on the changed leaves
+type HTMLChange struct {
+	Name       string
+	Path       string
+	ChangeType diff.ChangeType
+	Old        string
+	New        string
+	Children   []*HTMLChange
+}
+
+//defaultHTMLTitle is the report title when HTMLRenderer.Title is empty
+const defaultHTMLTitle = "Libra Diff Report"
+
+//HTMLTemplate is the default layout of the HTML report. The blocks could be redefined on a clone of DefaultHTMLTemplate
+const HTMLTemplate = `{{define "report"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+{{template "style"}}
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .ObjectTypes}}<section class="object-type">
+<h2>{{.Name}}</h2>
+{{range .Objects}}<div class="object {{.ChangeType}}">
+<h3>{{if .ID}}#{{.ID}}{{else}}(no id){{end}}</h3>
+<table>
+<thead><tr><th>Path</th><th>Old</th><th>New</th></tr></thead>
+{{range .Changes}}{{template "change" .}}{{end}}</table>
+</div>
+{{end}}</section>
+{{end}}</body>
+</html>
+{{end}}{{define "style"}}<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+td.value { font-family: monospace; white-space: pre-wrap; }
+tr.new td.new, tr.changed td.new { background: #e6ffed; }
+tr.removed td.old, tr.changed td.old { background: #ffeef0; }
+details { margin-left: 1em; }
+</style>{{end}}{{define "change"}}{{if .Children}}<tr class="nested"><td colspan="3"><details open><summary>{{.Name}}</summary>
+<table>
+{{range .Children}}{{template "change" .}}{{end}}</table>
+</details></td></tr>
+{{end}}{{if .ChangeType}}<tr class="{{.ChangeType}}"><td>{{if .Path}}{{.Path}}{{else}}(object){{end}}</td><td class="value old">{{.Old}}</td><td class="value new">{{.New}}</td></tr>
+{{end}}{{end}}`
+
+//DefaultHTMLTemplate returns the parsed default layout of the HTML report
+func DefaultHTMLTemplate() *template.Template {
+	return template.Must(template.New("report").Parse(HTMLTemplate))
+}
+
+//HTMLRenderer renders the diffs as a self-contained HTML report grouped by ObjectType and ObjectID
+type HTMLRenderer struct {
+	//Title is the title of the report
+	Title string
+
+	//Template overrides the layout of the report. It is executed with HTMLReport
+	Template *template.Template
+}
+
+var _ Renderer = (*HTMLRenderer)(nil)
+
+func (r *HTMLRenderer) Render(ctx context.Context, w io.Writer, diffs []diff.Diff) error {
+	tmpl := r.Template
+	if tmpl == nil {
+		tmpl = DefaultHTMLTemplate()
+	}
+
+	return tmpl.Execute(w, r.report(diffs))
+}
+
+func (r *HTMLRenderer) report(diffs []diff.Diff) HTMLReport {
+	report := HTMLReport{Title: r.Title}
+	if report.Title == "" {
+		report.Title = defaultHTMLTitle
+	}
+
+	index := map[string]int{}
+	for _, object := range diff.NewTree(diffs).Children {
+		i, ok := index[object.ObjectType]
+		if !ok {
+			i = len(report.ObjectTypes)
+			index[object.ObjectType] = i
+			report.ObjectTypes = append(report.ObjectTypes, HTMLObjectType{Name: object.ObjectType})
+		}
+
+		report.ObjectTypes[i].Objects = append(report.ObjectTypes[i].Objects, HTMLObject{
+			ID:         object.ObjectID,
+			ChangeType: objectChangeType(object),
+			Changes:    htmlChanges(object),
+		})
+	}
+
+	return report
+}
+
+func htmlChanges(object *diff.DiffNode) []*HTMLChange {
+	changes := []*HTMLChange{}
+	if object.Direct {
+		changes = append(changes, htmlChange(&diff.DiffNode{
+			ChangeType: object.ChangeType,
+			Direct:     true,
+			Old:        object.Old,
+			New:        object.New,
+		}))
+	}
+
+	for _, c := range object.Children {
+		changes = append(changes, htmlChange(c))
+	}
+
+	return changes
+}
+
+func htmlChange(n *diff.DiffNode) *HTMLChange {
+	change := &HTMLChange{
+		Name: n.Name,
+		Path: n.Path,
+	}
+
+	if n.Direct {
+		change.ChangeType = n.ChangeType
+		if n.ChangeType != diff.New {
+			change.Old = formatValue(n.Old)
+		}
+		if n.ChangeType != diff.Removed {
+			change.New = formatValue(n.New)
+		}
+	}
+
+	for _, c := range n.Children {
+		change.Children = append(change.Children, htmlChange(c))
+	}
+
+	return change
+}
@@ -0,0 +1,114 @@
+package render_test
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/render"
+)
+
+func TestHTMLRenderer_Render(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		diffs []diff.Diff
+	}
+	tests := []struct {
+		name     string
+		renderer *render.HTMLRenderer
+		args     args
+		contains []string
+		wantErr  bool
+	}{
+		{
+			"succeed when render the diffs grouped by object",
+			&render.HTMLRenderer{Title: "Person Changes"},
+			args{
+				ctx:   nil,
+				diffs: diffs,
+			},
+			[]string{
+				"<title>Person Changes</title>",
+				"<h2>render_test.person</h2>",
+				"<h3>#10</h3>",
+				`<tr class="changed"><td>Name</td><td class="value old">&#34;test1&#34;</td><td class="value new">&#34;test2&#34;</td></tr>`,
+				"<details open><summary>Address</summary>",
+				`<tr class="removed"><td>Address.City</td><td class="value old">&#34;Malang&#34;</td><td class="value new"></td></tr>`,
+				"<h2>map[string]interface {}</h2>",
+				"<h3>(no id)</h3>",
+			},
+			false,
+		}, {
+			"succeed when escape the values",
+			&render.HTMLRenderer{},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "string",
+					Field:      "<b>Name</b>",
+					Old:        "<script>alert(1)</script>",
+					New:        "a & b",
+				}},
+			},
+			[]string{
+				"<title>Libra Diff Report</title>",
+				"<td>&lt;b&gt;Name&lt;/b&gt;</td>",
+				"&#34;&lt;script&gt;alert(1)&lt;/script&gt;&#34;",
+				"&#34;a &amp; b&#34;",
+			},
+			false,
+		}, {
+			"succeed when render with custom template",
+			&render.HTMLRenderer{
+				Template: template.Must(template.New("custom").Parse(
+					`{{range .ObjectTypes}}{{.Name}}:{{range .Objects}}{{range .Changes}}{{.Path}}={{.New}};{{end}}{{end}}{{end}}`,
+				)),
+			},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "string",
+					Field:      "Name",
+					Old:        "foo",
+					New:        "bar",
+				}},
+			},
+			[]string{
+				"string:Name=&#34;bar&#34;;",
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := tt.renderer.Render(tt.args.ctx, w, tt.args.diffs); (err != nil) != tt.wantErr {
+				t.Errorf("HTMLRenderer.Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(w.String(), want) {
+					t.Errorf("HTMLRenderer.Render() = %s, want contains %s", w.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultHTMLTemplate(t *testing.T) {
+	tmpl := template.Must(render.DefaultHTMLTemplate().Parse(`{{define "style"}}<link rel="stylesheet" href="report.css">{{end}}`))
+
+	w := &bytes.Buffer{}
+	if err := (&render.HTMLRenderer{Template: tmpl}).Render(context.Background(), w, diffs); err != nil {
+		t.Fatalf("HTMLRenderer.Render() error = %v", err)
+	}
+
+	if !strings.Contains(w.String(), `<link rel="stylesheet" href="report.css">`) || strings.Contains(w.String(), "<style>") {
+		t.Errorf("HTMLRenderer.Render() = %s, want overridden style block", w.String())
+	}
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//defaultMaxValueLength is the longest value which is written inside of the Markdown table
+const defaultMaxValueLength = 80
+
+//MarkdownRenderer renders the diffs as GitHub-flavored Markdown with a table for each object
+type MarkdownRenderer struct {
+	//HeadingLevel is the level of the object headings, the default is 3
+	HeadingLevel int
+
+	//MaxValueLength is the longest value which is written inside of the table, the longer values are
+	//written into collapsed <details> blocks. Zero uses the default of 80 and negative disables the blocks
+	MaxValueLength int
+}
+
+var _ Renderer = (*MarkdownRenderer)(nil)
+
+type markdownDetail struct {
+	Summary string
+	Value   string
+}
+
+func (r *MarkdownRenderer) Render(ctx context.Context, w io.Writer, diffs []diff.Diff) error {
+	b := &strings.Builder{}
+	for i, object := range diff.NewTree(diffs).Children {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(b, "%s %s\n\n", strings.Repeat("#", r.headingLevel()), escapeMarkdown(objectName(object)))
+		b.WriteString("| Change | Path | Old | New |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+
+		details := []markdownDetail{}
+		for _, d := range object.Flatten() {
+			path := d.Field
+			if path == "" {
+				path = "(object)"
+			}
+
+			oldCell, newCell := "", ""
+			if d.ChangeType != diff.New {
+				oldCell = r.cell(path, "old", formatValue(d.Old), &details)
+			}
+			if d.ChangeType != diff.Removed {
+				newCell = r.cell(path, "new", formatValue(d.New), &details)
+			}
+
+			fmt.Fprintf(b, "| %s | %s | %s | %s |\n", marker(d.ChangeType), codeSpan(path), oldCell, newCell)
+		}
+
+		for _, detail := range details {
+			fence := codeFence(detail.Value)
+			fmt.Fprintf(b, "\n<details>\n<summary>%s</summary>\n\n%s\n%s\n%s\n\n</details>\n",
+				html.EscapeString(detail.Summary), fence, detail.Value, fence)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *MarkdownRenderer) cell(path, side, value string, details *[]markdownDetail) string {
+	maxLength := r.maxValueLength()
+	if maxLength < 0 || len([]rune(value)) <= maxLength && !strings.Contains(value, "\n") {
+		return codeSpan(value)
+	}
+
+	*details = append(*details, markdownDetail{
+		Summary: fmt.Sprintf("%s (%s value)", path, side),
+		Value:   value,
+	})
+
+	return codeSpan(truncate(firstLine(value), maxLength)) + " (see below)"
+}
+
+func (r *MarkdownRenderer) headingLevel() int {
+	if r.HeadingLevel <= 0 || r.HeadingLevel > 6 {
+		return 3
+	}
+
+	return r.HeadingLevel
+}
+
+func (r *MarkdownRenderer) maxValueLength() int {
+	if r.MaxValueLength == 0 {
+		return defaultMaxValueLength
+	}
+
+	return r.MaxValueLength
+}
+
+//MarkdownCodeSpan formats the text as inline code which is safe to be written inside of a Markdown table cell
+func MarkdownCodeSpan(s string) string {
+	return codeSpan(s)
+}
+
+//codeSpan formats the value as inline code which is safe to be written inside of a table cell
+func codeSpan(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.ReplaceAll(line, "|", `\|`)
+		delimiter := "`"
+		for strings.Contains(line, delimiter) {
+			delimiter += "`"
+		}
+
+		if delimiter != "`" {
+			lines[i] = delimiter + " " + line + " " + delimiter
+		} else {
+			lines[i] = delimiter + line + delimiter
+		}
+	}
+
+	return strings.Join(lines, "<br>")
+}
+
+func codeFence(s string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+
+	return fence
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "|", `\|`, "#", `\#`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,113 @@
+package render_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/render"
+)
+
+func TestMarkdownRenderer_Render(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		diffs []diff.Diff
+	}
+	tests := []struct {
+		name     string
+		renderer *render.MarkdownRenderer
+		args     args
+		want     string
+		wantErr  bool
+	}{
+		{
+			"succeed when render a table for each object",
+			&render.MarkdownRenderer{},
+			args{
+				ctx:   nil,
+				diffs: diffs,
+			},
+			"### render\\_test.person\\#10\n\n" +
+				"| Change | Path | Old | New |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| ~ | `Name` | `\"test1\"` | `\"test2\"` |\n" +
+				"| ~ | `Address.Street` | `\"jalan 123\"` | `\"jalan ABC\"` |\n" +
+				"| - | `Address.City` | `\"Malang\"` |  |\n" +
+				"\n" +
+				"### map\\[string\\]interface {}\n\n" +
+				"| Change | Path | Old | New |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| + | `Height` |  | `170` |\n",
+			false,
+		}, {
+			"succeed when escape the pipes, backticks and newlines",
+			&render.MarkdownRenderer{HeadingLevel: 2},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "config",
+					Field:      "Command",
+					Old:        []string{"a|b"},
+					New:        "run `make`\ntwice",
+				}},
+			},
+			"## config\n\n" +
+				"| Change | Path | Old | New |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| ~ | `Command` | `[a\\|b]` | `` \"run `make`\\ntwice\" `` |\n",
+			false,
+		}, {
+			"succeed when collapse the long values",
+			&render.MarkdownRenderer{MaxValueLength: 10},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.New,
+					ObjectType: "config",
+					Field:      "Description",
+					New:        "a very long description",
+				}},
+			},
+			"### config\n\n" +
+				"| Change | Path | Old | New |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| + | `Description` |  | `\"a very...` (see below) |\n" +
+				"\n<details>\n<summary>Description (new value)</summary>\n\n" +
+				"```\n\"a very long description\"\n```\n\n</details>\n",
+			false,
+		}, {
+			"succeed when escape the HTML of the collapsed value summary",
+			&render.MarkdownRenderer{MaxValueLength: 5},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.New,
+					ObjectType: "config",
+					Field:      "<b>&",
+					New:        "a long value",
+				}},
+			},
+			"### config\n\n" +
+				"| Change | Path | Old | New |\n" +
+				"| --- | --- | --- | --- |\n" +
+				"| + | `<b>&` |  | `\"a...` (see below) |\n" +
+				"\n<details>\n<summary>&lt;b&gt;&amp; (new value)</summary>\n\n" +
+				"```\n\"a long value\"\n```\n\n</details>\n",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := tt.renderer.Render(tt.args.ctx, w, tt.args.diffs); (err != nil) != tt.wantErr {
+				t.Errorf("MarkdownRenderer.Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("MarkdownRenderer.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
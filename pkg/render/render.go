@@ -0,0 +1,72 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//Renderer is used to render the diffs into a human readable format
+type Renderer interface {
+	Render(ctx context.Context, w io.Writer, diffs []diff.Diff) error
+}
+
+//objectName returns the display name of the object node
+func objectName(n *diff.DiffNode) string {
+	if n.ObjectID == "" {
+		return n.ObjectType
+	}
+
+	return fmt.Sprintf("%s#%s", n.ObjectType, n.ObjectID)
+}
+
+//objectChangeType returns the change type of the whole object, or Changed when only its fields are changed
+func objectChangeType(n *diff.DiffNode) diff.ChangeType {
+	if n.Direct && len(n.Children) == 0 {
+		return n.ChangeType
+	}
+
+	return diff.Changed
+}
+
+func marker(changeType diff.ChangeType) string {
+	switch changeType {
+	case diff.New:
+		return "+"
+	case diff.Removed:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return strconv.Quote(value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	if width <= 3 {
+		return string(runes[:width])
+	}
+
+	return string(runes[:width-3]) + "..."
+}
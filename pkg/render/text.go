@@ -0,0 +1,145 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+//minValueWidth is the minimum width of a value when the line is truncated
+const minValueWidth = 8
+
+//TextRenderer renders the diffs as a tree of changes with `+`, `-` and `~` markers
+type TextRenderer struct {
+	//Color enables the ANSI colors on the markers
+	Color bool
+
+	//Width is the maximum width of a line, the long values are truncated to fit. Zero disables the truncation
+	Width int
+
+	//Indent is the indentation for each level of the tree, the default is two spaces
+	Indent string
+}
+
+var _ Renderer = (*TextRenderer)(nil)
+
+func (r *TextRenderer) Render(ctx context.Context, w io.Writer, diffs []diff.Diff) error {
+	for _, object := range diff.NewTree(diffs).Children {
+		changeType := objectChangeType(object)
+		header := fmt.Sprintf("%s %s", marker(changeType), objectName(object))
+		if changeType != diff.Changed {
+			header = r.line(0, header, object)
+		}
+
+		if _, err := fmt.Fprintln(w, r.colorize(changeType, header, true)); err != nil {
+			return err
+		}
+
+		if changeType != diff.Changed {
+			continue
+		}
+
+		if err := r.renderChildren(w, object, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *TextRenderer) renderChildren(w io.Writer, n *diff.DiffNode, depth int) error {
+	for _, c := range n.Children {
+		indent := strings.Repeat(r.indent(), depth)
+
+		changeType := diff.Changed
+		text := indent + marker(changeType) + " " + c.Name
+		if c.Direct {
+			changeType = c.ChangeType
+			text = indent + r.line(len(indent), marker(changeType)+" "+c.Name, c)
+		}
+
+		if _, err := fmt.Fprintln(w, r.colorize(changeType, text, false)); err != nil {
+			return err
+		}
+
+		if err := r.renderChildren(w, c, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//line formats the label and the values of the node within the configured width
+func (r *TextRenderer) line(offset int, label string, d *diff.DiffNode) string {
+	switch d.ChangeType {
+	case diff.New:
+		return fmt.Sprintf("%s: %s", label, truncate(formatValue(d.New), r.valueWidth(offset+len(label)+2, 1)))
+	case diff.Removed:
+		return fmt.Sprintf("%s: %s", label, truncate(formatValue(d.Old), r.valueWidth(offset+len(label)+2, 1)))
+	default:
+		width := r.valueWidth(offset+len(label)+6, 2)
+		return fmt.Sprintf("%s: %s -> %s", label, truncate(formatValue(d.Old), width), truncate(formatValue(d.New), width))
+	}
+}
+
+func (r *TextRenderer) valueWidth(used, values int) int {
+	if r.Width <= 0 {
+		return 0
+	}
+
+	width := (r.Width - used) / values
+	if width < minValueWidth {
+		return minValueWidth
+	}
+
+	return width
+}
+
+func (r *TextRenderer) indent() string {
+	if r.Indent == "" {
+		return "  "
+	}
+
+	return r.Indent
+}
+
+func (r *TextRenderer) colorize(changeType diff.ChangeType, text string, bold bool) string {
+	if !r.Color {
+		return text
+	}
+
+	trimmed := strings.TrimLeft(text, " \t")
+	indent := text[:len(text)-len(trimmed)]
+
+	color := ""
+	switch changeType {
+	case diff.New:
+		color = colorGreen
+	case diff.Removed:
+		color = colorRed
+	case diff.Changed:
+		color = colorYellow
+	}
+
+	if bold {
+		color = colorBold + color
+	}
+
+	if color == "" || trimmed == "" {
+		return text
+	}
+
+	return indent + color + trimmed + colorReset
+}
@@ -0,0 +1,133 @@
+package render_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/render"
+)
+
+var diffs = []diff.Diff{{
+	ChangeType: diff.Changed,
+	ObjectType: "render_test.person",
+	ObjectID:   "10",
+	Field:      "Name",
+	Old:        "test1",
+	New:        "test2",
+}, {
+	ChangeType: diff.Changed,
+	ObjectType: "render_test.person",
+	ObjectID:   "10",
+	Field:      "Address.Street",
+	Old:        "jalan 123",
+	New:        "jalan ABC",
+}, {
+	ChangeType: diff.Removed,
+	ObjectType: "render_test.person",
+	ObjectID:   "10",
+	Field:      "Address.City",
+	Old:        "Malang",
+}, {
+	ChangeType: diff.New,
+	ObjectType: "map[string]interface {}",
+	Field:      "Height",
+	New:        170,
+}}
+
+func TestTextRenderer_Render(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		diffs []diff.Diff
+	}
+	tests := []struct {
+		name     string
+		renderer *render.TextRenderer
+		args     args
+		want     string
+		wantErr  bool
+	}{
+		{
+			"succeed when render the diffs as a tree",
+			&render.TextRenderer{},
+			args{
+				ctx:   nil,
+				diffs: diffs,
+			},
+			"~ render_test.person#10\n" +
+				"  ~ Name: \"test1\" -> \"test2\"\n" +
+				"  ~ Address\n" +
+				"    ~ Street: \"jalan 123\" -> \"jalan ABC\"\n" +
+				"    - City: \"Malang\"\n" +
+				"~ map[string]interface {}\n" +
+				"  + Height: 170\n",
+			false,
+		}, {
+			"succeed when render the new object",
+			&render.TextRenderer{},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.New,
+					ObjectType: "string",
+					New:        "foo",
+				}},
+			},
+			"+ string: \"foo\"\n",
+			false,
+		}, {
+			"succeed when truncate the long values",
+			&render.TextRenderer{Width: 30},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.Changed,
+					ObjectType: "string",
+					Field:      "Description",
+					Old:        "a very long description",
+					New:        "short",
+				}},
+			},
+			"~ string\n" +
+				"  ~ Description: \"a ve... -> \"short\"\n",
+			false,
+		}, {
+			"succeed when render with colors",
+			&render.TextRenderer{Color: true, Indent: "\t"},
+			args{
+				ctx: nil,
+				diffs: []diff.Diff{{
+					ChangeType: diff.Removed,
+					ObjectType: "string",
+					Field:      "Name",
+					Old:        "foo",
+				}},
+			},
+			"\x1b[1m\x1b[33m~ string\x1b[0m\n" +
+				"\t\x1b[31m- Name: \"foo\"\x1b[0m\n",
+			false,
+		}, {
+			"succeed when render the empty diffs",
+			&render.TextRenderer{},
+			args{
+				ctx:   nil,
+				diffs: []diff.Diff{},
+			},
+			"",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := tt.renderer.Render(tt.args.ctx, w, tt.args.diffs); (err != nil) != tt.wantErr {
+				t.Errorf("TextRenderer.Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := w.String(); got != tt.want {
+				t.Errorf("TextRenderer.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
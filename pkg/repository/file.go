@@ -0,0 +1,628 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+const (
+	commitsFileName   = "commits.jsonl"
+	snapshotsFileName = "snapshots.jsonl"
+)
+
+//SyncMode decides when the written records are flushed into the disk
+type SyncMode int
+
+const (
+	//SyncEveryCommit calls fsync after every commit, it is the default mode
+	SyncEveryCommit SyncMode = iota
+
+	//SyncNone leaves the flushing to the operating system
+	SyncNone
+)
+
+//FileOptions configures the FileRepository
+type FileOptions struct {
+	//Sync decides when the written records are flushed into the disk
+	Sync SyncMode
+
+	//Registry resolves the value types of the stored diffs. The types of the committed objects are registered automatically,
+	//while the types of the objects which are committed by the previous processes should be registered before reading them.
+	//The values of the types which are not registered are read as the generic JSON values
+	Registry *codec.Registry
+}
+
+//FileRepository is a Repository which appends the commits and snapshots into JSON Lines files inside of a directory.
+//A snapshot is written before its commit, so a crash between them leaves only an ignored snapshot behind,
+//and a partially written line at the end of a file is truncated when the repository is opened
+type FileRepository struct {
+	mu        sync.RWMutex
+	dir       string
+	options   FileOptions
+	commits   *logFile
+	snapshots *logFile
+	sequence  int64
+	objects   map[objectKey][]fileEntry
+}
+
+var (
+	_ Repository = (*FileRepository)(nil)
+	_ Compactor  = (*FileRepository)(nil)
+)
+
+//fileCommit is the stored form of Commit which keeps the type of the diff values
+type fileCommit struct {
+	Sequence   int64             `json:"sequence"`
+	ObjectType string            `json:"object_type"`
+	ObjectID   string            `json:"object_id"`
+	Version    int64             `json:"version"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Author     string            `json:"author,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Diffs      []codec.TypedDiff `json:"diffs"`
+}
+
+//fileEntry is the index entry of a version of an object
+type fileEntry struct {
+	Version  int64
+	Sequence int64
+	Commit   span
+	Snapshot span
+}
+
+type span struct {
+	Offset int64
+	Length int64
+}
+
+//logFile is an append-only JSON Lines file
+type logFile struct {
+	file fileHandle
+	size int64
+}
+
+//fileHandle is the part of *os.File which is used by logFile
+type fileHandle interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Name() string
+	Sync() error
+	Truncate(size int64) error
+}
+
+//OpenFileRepository opens the repository inside of the directory, which is created when it does not exist, and rebuilds its index
+func OpenFileRepository(dir string, options FileOptions) (*FileRepository, error) {
+	if options.Registry == nil {
+		options.Registry = codec.NewRegistry()
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	r := &FileRepository{
+		dir:     dir,
+		options: options,
+		objects: map[objectKey][]fileEntry{},
+	}
+
+	var err error
+	if r.snapshots, err = openLogFile(filepath.Join(dir, snapshotsFileName)); err != nil {
+		return nil, err
+	}
+
+	if r.commits, err = openLogFile(filepath.Join(dir, commitsFileName)); err != nil {
+		r.snapshots.file.Close()
+		return nil, err
+	}
+
+	if err := r.rebuildIndex(); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//Register registers the types of the committed objects which are stored by the previous processes
+func (r *FileRepository) Register(values ...interface{}) {
+	r.options.Registry.Register(values...)
+}
+
+//Close closes the underlying files
+func (r *FileRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshotsErr := r.snapshots.file.Close()
+	if err := r.commits.file.Close(); err != nil {
+		return err
+	}
+
+	return snapshotsErr
+}
+
+func (r *FileRepository) rebuildIndex() error {
+	snapshots := map[int64]span{}
+	err := r.snapshots.scan(func(line []byte, s span) error {
+		snapshot := Snapshot{}
+		if err := json.Unmarshal(line, &snapshot); err != nil {
+			return err
+		}
+		snapshots[snapshot.Sequence] = s
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error on read %s Error : %s", snapshotsFileName, err.Error())
+	}
+
+	err = r.commits.scan(func(line []byte, s span) error {
+		commit := fileCommit{}
+		if err := json.Unmarshal(line, &commit); err != nil {
+			return err
+		}
+
+		snapshot, ok := snapshots[commit.Sequence]
+		if !ok {
+			return fmt.Errorf("snapshot of commit %d is not found", commit.Sequence)
+		}
+
+		key := objectKey{ObjectType: commit.ObjectType, ObjectID: commit.ObjectID}
+		r.objects[key] = append(r.objects[key], fileEntry{
+			Version:  commit.Version,
+			Sequence: commit.Sequence,
+			Commit:   s,
+			Snapshot: snapshot,
+		})
+
+		if commit.Sequence > r.sequence {
+			r.sequence = commit.Sequence
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error on read %s Error : %s", commitsFileName, err.Error())
+	}
+
+	return nil
+}
+
+func (r *FileRepository) Commit(ctx context.Context, obj interface{}) (*Commit, error) {
+	v, key, err := identify(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var previous *Snapshot
+	entries := r.objects[key]
+	if len(entries) > 0 {
+		if previous, err = r.readSnapshot(entries[len(entries)-1]); err != nil {
+			return nil, err
+		}
+	}
+
+	change, err := compareSnapshot(ctx, v, key, previous)
+	if err != nil || change == nil {
+		return nil, err
+	}
+
+	version := int64(1)
+	if previous != nil {
+		version = previous.Version + 1
+	}
+
+	commit := newCommit(ctx, change, r.sequence+1, version)
+
+	stored, err := r.encodeCommit(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotSpan, err := r.snapshots.append(Snapshot{
+		ObjectType: key.ObjectType,
+		ObjectID:   key.ObjectID,
+		Version:    version,
+		Sequence:   commit.Sequence,
+		Timestamp:  commit.Timestamp,
+		State:      change.State,
+	}, r.options.Sync)
+	if err != nil {
+		return nil, err
+	}
+
+	commitSpan, err := r.commits.append(stored, r.options.Sync)
+	if err != nil {
+		return nil, err
+	}
+
+	r.sequence = commit.Sequence
+	r.objects[key] = append(r.objects[key], fileEntry{
+		Version:  version,
+		Sequence: commit.Sequence,
+		Commit:   commitSpan,
+		Snapshot: snapshotSpan,
+	})
+
+	return &commit, nil
+}
+
+func (r *FileRepository) Commits(ctx context.Context, objectType, objectID string) ([]Commit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := r.objects[objectKey{ObjectType: objectType, ObjectID: objectID}]
+	commits := make([]Commit, 0, len(entries))
+	for _, entry := range entries {
+		commit, err := r.readCommit(entry)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, *commit)
+	}
+
+	return commits, nil
+}
+
+func (r *FileRepository) Snapshots(ctx context.Context, objectType, objectID string) ([]Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := r.objects[objectKey{ObjectType: objectType, ObjectID: objectID}]
+	snapshots := make([]Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		snapshot, err := r.readSnapshot(entry)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (r *FileRepository) FindChanges(ctx context.Context, q Query) ([]Change, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []fileEntry{}
+	for key, objectEntries := range r.objects {
+		if q.matchObject(key.ObjectType, key.ObjectID) {
+			entries = append(entries, objectEntries...)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+
+	commits := make([]Commit, 0, len(entries))
+	for _, entry := range entries {
+		commit, err := r.readCommit(entry)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, *commit)
+	}
+
+	return q.collect(commits), nil
+}
+
+//Compact rewrites the retained commits and snapshots into temporary files which replace the current ones.
+//The commits file is replaced first, so a crash between the replacements only leaves the ignored snapshots behind
+func (r *FileRepository) Compact(ctx context.Context, policy RetentionPolicy) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	entries := []fileEntry{}
+	retained := map[int64]Commit{}
+	replaced := map[int64]bool{}
+	for _, objectEntries := range r.objects {
+		commits := make([]Commit, 0, len(objectEntries))
+		for _, entry := range objectEntries {
+			commit, err := r.readCommit(entry)
+			if err != nil {
+				return 0, err
+			}
+			commits = append(commits, *commit)
+		}
+
+		if err := retain(ctx, policy, now, commits, retained, replaced); err != nil {
+			return 0, err
+		}
+		entries = append(entries, objectEntries...)
+	}
+
+	if len(retained) == len(entries) && len(replaced) == 0 {
+		return 0, nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Sequence < entries[j].Sequence
+	})
+
+	commits, snapshots, err := r.writeCompacted(entries, retained)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(commits.file.Name(), filepath.Join(r.dir, commitsFileName)); err != nil {
+		commits.remove()
+		snapshots.remove()
+		return 0, err
+	}
+	r.commits.file.Close()
+	r.commits = commits
+
+	//The current snapshots file is the superset of the compacted one, so it could still be used when the replacement fails
+	err = os.Rename(snapshots.file.Name(), filepath.Join(r.dir, snapshotsFileName))
+	if err != nil {
+		snapshots.remove()
+	} else {
+		r.snapshots.file.Close()
+		r.snapshots = snapshots
+		err = syncDir(r.dir)
+	}
+
+	r.objects = map[objectKey][]fileEntry{}
+	if rebuildErr := r.rebuildIndex(); rebuildErr != nil {
+		return 0, rebuildErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(entries) - len(retained), nil
+}
+
+//writeCompacted writes the retained commits and their snapshots into the temporary files
+func (r *FileRepository) writeCompacted(entries []fileEntry, retained map[int64]Commit) (*logFile, *logFile, error) {
+	commits, err := createLogFile(filepath.Join(r.dir, commitsFileName+".tmp"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshots, err := createLogFile(filepath.Join(r.dir, snapshotsFileName+".tmp"))
+	if err != nil {
+		commits.remove()
+		return nil, nil, err
+	}
+
+	err = func() error {
+		for _, entry := range entries {
+			commit, ok := retained[entry.Sequence]
+			if !ok {
+				continue
+			}
+
+			snapshot, err := r.readSnapshot(entry)
+			if err != nil {
+				return err
+			}
+			if _, err := snapshots.append(snapshot, SyncNone); err != nil {
+				return err
+			}
+
+			stored, err := r.encodeCommit(commit)
+			if err != nil {
+				return err
+			}
+			if _, err := commits.append(stored, SyncNone); err != nil {
+				return err
+			}
+		}
+
+		if err := commits.file.Sync(); err != nil {
+			return err
+		}
+		return snapshots.file.Sync()
+	}()
+	if err != nil {
+		commits.remove()
+		snapshots.remove()
+		return nil, nil, err
+	}
+
+	return commits, snapshots, nil
+}
+
+//register registers the types of the non-nil values
+func (r *FileRepository) register(values ...interface{}) {
+	for _, v := range values {
+		if v != nil {
+			r.options.Registry.Register(v)
+		}
+	}
+}
+
+func (r *FileRepository) encodeCommit(commit Commit) (*fileCommit, error) {
+	stored := &fileCommit{
+		Sequence:   commit.Sequence,
+		ObjectType: commit.ObjectType,
+		ObjectID:   commit.ObjectID,
+		Version:    commit.Version,
+		Timestamp:  commit.Timestamp,
+		Author:     commit.Author,
+		Properties: commit.Properties,
+		Diffs:      make([]codec.TypedDiff, 0, len(commit.Diffs)),
+	}
+	for _, d := range commit.Diffs {
+		r.register(d.Old, d.New)
+
+		td, err := codec.EncodeTyped(d)
+		if err != nil {
+			return nil, err
+		}
+		stored.Diffs = append(stored.Diffs, td)
+	}
+
+	return stored, nil
+}
+
+func (r *FileRepository) readCommit(entry fileEntry) (*Commit, error) {
+	stored := fileCommit{}
+	if err := r.commits.read(entry.Commit, &stored); err != nil {
+		return nil, err
+	}
+
+	commit := &Commit{
+		Sequence:   stored.Sequence,
+		ObjectType: stored.ObjectType,
+		ObjectID:   stored.ObjectID,
+		Version:    stored.Version,
+		Timestamp:  stored.Timestamp,
+		Author:     stored.Author,
+		Properties: stored.Properties,
+		Diffs:      make([]diff.Diff, 0, len(stored.Diffs)),
+	}
+	for _, td := range stored.Diffs {
+		d, err := decodeTyped(td, r.options.Registry)
+		if err != nil {
+			return nil, fmt.Errorf("error on decode commit %d Error : %s", stored.Sequence, err.Error())
+		}
+		commit.Diffs = append(commit.Diffs, d)
+	}
+
+	return commit, nil
+}
+
+func (r *FileRepository) readSnapshot(entry fileEntry) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := r.snapshots.read(entry.Snapshot, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func openLogFile(name string) (*logFile, error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logFile{file: file}, nil
+}
+
+//createLogFile creates an empty log file, the existing file is truncated
+func createLogFile(name string) (*logFile, error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logFile{file: file}, nil
+}
+
+//remove closes and removes the file
+func (l *logFile) remove() {
+	l.file.Close()
+	os.Remove(l.file.Name())
+}
+
+//syncDir flushes the renamed entries of the directory
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+//scan calls fn for each of the complete lines. A partially written or corrupted last line is truncated
+func (l *logFile) scan(fn func(line []byte, s span) error) error {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(l.file)
+	offset := int64(0)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				return l.truncate(offset)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		s := span{Offset: offset, Length: int64(len(line))}
+		if err := fn(line, s); err != nil {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				return l.truncate(offset)
+			}
+			return fmt.Errorf("corrupted line at offset %d Error : %s", offset, err.Error())
+		}
+		offset += s.Length
+	}
+
+	l.size = offset
+	return nil
+}
+
+func (l *logFile) truncate(size int64) error {
+	if err := l.file.Truncate(size); err != nil {
+		return err
+	}
+
+	l.size = size
+	return l.file.Sync()
+}
+
+//append writes the record as a single line at the end of the file
+func (l *logFile) append(record interface{}, mode SyncMode) (span, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return span{}, err
+	}
+	line = append(line, '\n')
+
+	if _, err := l.file.WriteAt(line, l.size); err != nil {
+		//Drop the partially written line, so the next record starts on a clean offset
+		l.file.Truncate(l.size)
+		return span{}, err
+	}
+
+	if mode == SyncEveryCommit {
+		if err := l.file.Sync(); err != nil {
+			//The line may not be durable, so it is dropped the same way as a failed write
+			l.file.Truncate(l.size)
+			return span{}, err
+		}
+	}
+
+	s := span{Offset: l.size, Length: int64(len(line))}
+	l.size += s.Length
+
+	return s, nil
+}
+
+func (l *logFile) read(s span, record interface{}) error {
+	line := make([]byte, s.Length)
+	if _, err := l.file.ReadAt(line, s.Offset); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(line, record)
+}
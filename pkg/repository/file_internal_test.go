@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type failingSyncer struct {
+	fileHandle
+}
+
+func (f failingSyncer) Sync() error {
+	return errors.New("sync failed")
+}
+
+func TestLogFile_AppendSyncError(t *testing.T) {
+	l, err := openLogFile(filepath.Join(t.TempDir(), commitsFileName))
+	if err != nil {
+		t.Fatalf("openLogFile() error = %v", err)
+	}
+	defer l.file.Close()
+
+	if _, err := l.append(map[string]int{"a": 1}, SyncNone); err != nil {
+		t.Fatalf("append() error = %v", err)
+	}
+	size := l.size
+
+	file := l.file
+	l.file = failingSyncer{fileHandle: file}
+	if _, err := l.append(map[string]int{"b": 2}, SyncEveryCommit); err == nil {
+		t.Fatalf("append() error = nil, want the sync error")
+	}
+
+	info, err := os.Stat(file.Name())
+	if err != nil {
+		t.Fatalf("os.Stat() error = %v", err)
+	}
+	if l.size != size || info.Size() != size {
+		t.Errorf("append() left the size %d and the file size %d, want %d", l.size, info.Size(), size)
+	}
+
+	l.file = file
+	s, err := l.append(map[string]int{"c": 3}, SyncNone)
+	if err != nil {
+		t.Fatalf("append() error = %v", err)
+	}
+	got := map[string]int{}
+	if err := l.read(s, &got); err != nil || s.Offset != size || got["c"] != 3 {
+		t.Errorf("read() = %v, %v at offset %d, want the next record at offset %d", got, err, s.Offset, size)
+	}
+}
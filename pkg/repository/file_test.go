@@ -0,0 +1,194 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/repository"
+)
+
+func openFileRepository(t *testing.T, dir string, options repository.FileOptions) *repository.FileRepository {
+	repo, err := repository.OpenFileRepository(dir, options)
+	if err != nil {
+		t.Fatalf("OpenFileRepository() error = %v", err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	return repo
+}
+
+func TestFileRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) repository.Repository {
+		return openFileRepository(t, t.TempDir(), repository.FileOptions{})
+	})
+}
+
+func TestFileRepository_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openFileRepository(t, dir, repository.FileOptions{Sync: repository.SyncNone})
+	if _, err := repo.Commit(ctx, person{ID: 1, Name: "test1"}); err != nil {
+		t.Fatalf("FileRepository.Commit() error = %v", err)
+	}
+	if _, err := repo.Commit(ctx, person{ID: 1, Name: "test2"}); err != nil {
+		t.Fatalf("FileRepository.Commit() error = %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("FileRepository.Close() error = %v", err)
+	}
+
+	//Simulate a crash in the middle of writing a commit
+	commits, err := os.OpenFile(filepath.Join(dir, "commits.jsonl"), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("os.OpenFile() error = %v", err)
+	}
+	commits.WriteString(`{"sequence":3,"object_type":"repos`)
+	commits.Close()
+
+	reopened := openFileRepository(t, dir, repository.FileOptions{})
+	reopened.Register(person{})
+
+	commit, err := reopened.Commit(ctx, person{ID: 1, Name: "test3"})
+	if err != nil {
+		t.Fatalf("FileRepository.Commit() error = %v", err)
+	}
+	if commit.Sequence != 3 || commit.Version != 3 {
+		t.Errorf("FileRepository.Commit() = %+v, want sequence 3 and version 3", commit)
+	}
+
+	history, err := reopened.Commits(ctx, "repository_test.person", "1")
+	if err != nil {
+		t.Fatalf("FileRepository.Commits() error = %v", err)
+	}
+
+	want := [][]diff.Diff{{{
+		ChangeType: diff.New,
+		ObjectType: "repository_test.person",
+		ObjectID:   "1",
+		New:        person{ID: 1, Name: "test1"},
+	}}, {{
+		ChangeType: diff.Changed,
+		ObjectType: "repository_test.person",
+		ObjectID:   "1",
+		Field:      "Name",
+		Old:        "test1",
+		New:        "test2",
+	}}, {{
+		ChangeType: diff.Changed,
+		ObjectType: "repository_test.person",
+		ObjectID:   "1",
+		Field:      "Name",
+		Old:        "test2",
+		New:        "test3",
+	}}}
+	if len(history) != len(want) {
+		t.Fatalf("FileRepository.Commits() = %+v, want %d commits", history, len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(history[i].Diffs, want[i]) {
+			t.Errorf("FileRepository.Commits()[%d].Diffs = %v, want %v", i, history[i].Diffs, want[i])
+		}
+	}
+}
+
+func TestOpenFileRepository_Corrupted(t *testing.T) {
+	dir := t.TempDir()
+	content := "not a json\n{\"sequence\":1}\n"
+	if err := os.WriteFile(filepath.Join(dir, "commits.jsonl"), []byte(content), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	if _, err := repository.OpenFileRepository(dir, repository.FileOptions{}); err == nil {
+		t.Errorf("OpenFileRepository() error = nil, wantErr true")
+	}
+}
+
+func TestFileRepository_CompactReopen(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openFileRepository(t, dir, repository.FileOptions{})
+	for _, name := range []string{"test1", "test2", "test3"} {
+		if _, err := repo.Commit(ctx, person{ID: 1, Name: name}); err != nil {
+			t.Fatalf("FileRepository.Commit() error = %v", err)
+		}
+	}
+
+	if removed, err := repo.Compact(ctx, repository.KeepLast(1)); err != nil || removed != 2 {
+		t.Fatalf("FileRepository.Compact() = %v, %v, want 2", removed, err)
+	}
+
+	if _, err := repo.Commit(ctx, person{ID: 1, Name: "test4"}); err != nil {
+		t.Fatalf("FileRepository.Commit() error = %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("FileRepository.Close() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "commits.jsonl.tmp")); !os.IsNotExist(err) {
+		t.Errorf("os.Stat() error = %v, want the temporary file to be renamed", err)
+	}
+
+	reopened := openFileRepository(t, dir, repository.FileOptions{})
+	reopened.Register(person{})
+
+	commits, err := reopened.Commits(ctx, "repository_test.person", "1")
+	if err != nil {
+		t.Fatalf("FileRepository.Commits() error = %v", err)
+	}
+
+	versions := []int64{}
+	for _, commit := range commits {
+		versions = append(versions, commit.Version)
+	}
+	if want := []int64{3, 4}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("FileRepository.Commits() versions = %v, want %v", versions, want)
+	}
+
+	got := person{}
+	if err := repository.Shadow(ctx, reopened, &got, "1", 4); err != nil || got.Name != "test4" {
+		t.Errorf("Shadow() = %v, %v, want test4", got, err)
+	}
+}
+
+func TestFileRepository_ReopenUnregistered(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo := openFileRepository(t, dir, repository.FileOptions{})
+	for _, name := range []string{"test1", "test2"} {
+		if _, err := repo.Commit(ctx, person{ID: 1, Name: name}); err != nil {
+			t.Fatalf("FileRepository.Commit() error = %v", err)
+		}
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("FileRepository.Close() error = %v", err)
+	}
+
+	reopened := openFileRepository(t, dir, repository.FileOptions{})
+	changes, err := reopened.FindChanges(ctx, repository.Query{OldestFirst: true})
+	if err != nil {
+		t.Fatalf("FileRepository.FindChanges() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"ID":      float64(1),
+		"Name":    "test1",
+		"Age":     float64(0),
+		"Address": map[string]interface{}{"Street": "", "City": ""},
+	}
+	if len(changes) != 2 || !reflect.DeepEqual(changes[0].New, want) || changes[1].New != "test2" {
+		t.Errorf("FileRepository.FindChanges() = %+v, want the generic value %v", changes, want)
+	}
+
+	if removed, err := reopened.Compact(ctx, repository.KeepLast(1)); err != nil || removed != 1 {
+		t.Errorf("FileRepository.Compact() = %v, %v, want 1", removed, err)
+	}
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//MemoryRepository is a Repository which keeps the commits and snapshots in memory
+type MemoryRepository struct {
+	mu        sync.RWMutex
+	sequence  int64
+	commits   []Commit
+	snapshots map[objectKey][]Snapshot
+	objects   map[objectKey][]int
+}
+
+var (
+	_ Repository = (*MemoryRepository)(nil)
+	_ Compactor  = (*MemoryRepository)(nil)
+)
+
+//NewMemoryRepository returns an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		commits:   []Commit{},
+		snapshots: map[objectKey][]Snapshot{},
+		objects:   map[objectKey][]int{},
+	}
+}
+
+func (r *MemoryRepository) Commit(ctx context.Context, obj interface{}) (*Commit, error) {
+	v, key, err := identify(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var previous *Snapshot
+	if snapshots := r.snapshots[key]; len(snapshots) > 0 {
+		previous = &snapshots[len(snapshots)-1]
+	}
+
+	change, err := compareSnapshot(ctx, v, key, previous)
+	if err != nil || change == nil {
+		return nil, err
+	}
+
+	version := int64(1)
+	if previous != nil {
+		version = previous.Version + 1
+	}
+
+	r.sequence++
+	commit := newCommit(ctx, change, r.sequence, version)
+
+	r.objects[key] = append(r.objects[key], len(r.commits))
+	r.commits = append(r.commits, commit)
+	r.snapshots[key] = append(r.snapshots[key], Snapshot{
+		ObjectType: key.ObjectType,
+		ObjectID:   key.ObjectID,
+		Version:    version,
+		Sequence:   commit.Sequence,
+		Timestamp:  commit.Timestamp,
+		State:      change.State,
+	})
+
+	return &commit, nil
+}
+
+func (r *MemoryRepository) Commits(ctx context.Context, objectType, objectID string) ([]Commit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	indexes := r.objects[objectKey{ObjectType: objectType, ObjectID: objectID}]
+	commits := make([]Commit, 0, len(indexes))
+	for _, i := range indexes {
+		commits = append(commits, r.commits[i])
+	}
+
+	return commits, nil
+}
+
+func (r *MemoryRepository) Snapshots(ctx context.Context, objectType, objectID string) ([]Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots := r.snapshots[objectKey{ObjectType: objectType, ObjectID: objectID}]
+
+	return append([]Snapshot{}, snapshots...), nil
+}
+
+func (r *MemoryRepository) FindChanges(ctx context.Context, q Query) ([]Change, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return q.collect(r.commits), nil
+}
+
+func (r *MemoryRepository) Compact(ctx context.Context, policy RetentionPolicy) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	retained := map[int64]Commit{}
+	replaced := map[int64]bool{}
+	for _, indexes := range r.objects {
+		commits := make([]Commit, 0, len(indexes))
+		for _, i := range indexes {
+			commits = append(commits, r.commits[i])
+		}
+
+		if err := retain(ctx, policy, now, commits, retained, replaced); err != nil {
+			return 0, err
+		}
+	}
+
+	commits := make([]Commit, 0, len(retained))
+	objects := map[objectKey][]int{}
+	for _, commit := range r.commits {
+		if commit, ok := retained[commit.Sequence]; ok {
+			key := objectKey{ObjectType: commit.ObjectType, ObjectID: commit.ObjectID}
+			objects[key] = append(objects[key], len(commits))
+			commits = append(commits, commit)
+		}
+	}
+
+	for key, snapshots := range r.snapshots {
+		kept := []Snapshot{}
+		for _, snapshot := range snapshots {
+			if _, ok := retained[snapshot.Sequence]; ok {
+				kept = append(kept, snapshot)
+			}
+		}
+		r.snapshots[key] = kept
+	}
+
+	removed := len(r.commits) - len(commits)
+	r.commits = commits
+	r.objects = objects
+
+	return removed, nil
+}
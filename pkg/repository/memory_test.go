@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/repository"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) repository.Repository {
+		return repository.NewMemoryRepository()
+	})
+}
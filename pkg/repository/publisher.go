@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+//Listener is called synchronously with every commit after it is persisted
+type Listener func(ctx context.Context, commit Commit)
+
+//BackPressure decides what happens to a commit when the buffer of a subscription is full
+type BackPressure int
+
+const (
+	//Block waits until the subscriber receives the commit, the subscription is closed or the context is done
+	Block BackPressure = iota
+
+	//DropNewest discards the commit which is being published
+	DropNewest
+
+	//DropOldest discards the oldest buffered commit to make room for the one which is being published
+	DropOldest
+)
+
+//Publisher is a Repository which publishes the persisted commits to its listeners and subscriptions.
+//The commits are published in sequence order, the subscribers should not modify the received commits since they are shared
+type Publisher struct {
+	Repository
+
+	mu            sync.RWMutex
+	commitMu      sync.Mutex
+	nextID        int
+	listeners     map[int]Listener
+	listenerIDs   []int
+	subscriptions map[*Subscription]struct{}
+}
+
+//NewPublisher returns a Publisher on top of the repository
+func NewPublisher(repo Repository) *Publisher {
+	return &Publisher{
+		Repository:    repo,
+		listeners:     map[int]Listener{},
+		listenerIDs:   []int{},
+		subscriptions: map[*Subscription]struct{}{},
+	}
+}
+
+//Commit commits the object through the underlying repository and publishes the commit when the object has changes
+func (p *Publisher) Commit(ctx context.Context, obj interface{}) (*Commit, error) {
+	p.commitMu.Lock()
+	defer p.commitMu.Unlock()
+
+	commit, err := p.Repository.Commit(ctx, obj)
+	if err != nil || commit == nil {
+		return commit, err
+	}
+
+	p.publish(ctx, *commit)
+
+	return commit, nil
+}
+
+//Publish publishes a commit which is persisted outside of the publisher, e.g. by SQLRepository.CommitTx
+//after its transaction is committed
+func (p *Publisher) Publish(ctx context.Context, commit Commit) {
+	p.commitMu.Lock()
+	defer p.commitMu.Unlock()
+
+	p.publish(ctx, commit)
+}
+
+//Listen registers the listener, the returned function unregisters it
+func (p *Publisher) Listen(listener Listener) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.nextID
+	p.nextID++
+	p.listeners[id] = listener
+	p.listenerIDs = append(p.listenerIDs, id)
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		delete(p.listeners, id)
+		for i, listenerID := range p.listenerIDs {
+			if listenerID == id {
+				p.listenerIDs = append(p.listenerIDs[:i], p.listenerIDs[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+//Subscribe returns a subscription which buffers up to size commits, the back pressure applies when the buffer is full
+func (p *Publisher) Subscribe(size int, backPressure BackPressure) *Subscription {
+	if size < 0 {
+		size = 0
+	}
+
+	s := &Subscription{
+		publisher:    p,
+		backPressure: backPressure,
+		commits:      make(chan Commit, size),
+		done:         make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	p.subscriptions[s] = struct{}{}
+	p.mu.Unlock()
+
+	return s
+}
+
+func (p *Publisher) publish(ctx context.Context, commit Commit) {
+	p.mu.RLock()
+	listeners := make([]Listener, 0, len(p.listenerIDs))
+	for _, id := range p.listenerIDs {
+		listeners = append(listeners, p.listeners[id])
+	}
+	subscriptions := make([]*Subscription, 0, len(p.subscriptions))
+	for s := range p.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	p.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(ctx, commit)
+	}
+
+	for _, s := range subscriptions {
+		s.send(ctx, commit)
+	}
+}
+
+//Subscription receives the published commits through a buffered channel
+type Subscription struct {
+	publisher    *Publisher
+	backPressure BackPressure
+	commits      chan Commit
+	done         chan struct{}
+	closeOnce    sync.Once
+
+	mu      sync.Mutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+//C returns the channel of the commits, it is closed when the subscription is closed
+func (s *Subscription) C() <-chan Commit {
+	return s.commits
+}
+
+//Dropped returns the number of the commits which are discarded by the back pressure
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+//Close unsubscribes from the publisher and closes the channel, the buffered commits could still be received
+func (s *Subscription) Close() {
+	s.publisher.mu.Lock()
+	delete(s.publisher.subscriptions, s)
+	s.publisher.mu.Unlock()
+
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.closed = true
+		close(s.commits)
+	})
+}
+
+func (s *Subscription) send(ctx context.Context, commit Commit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.backPressure {
+	case DropNewest:
+		select {
+		case s.commits <- commit:
+		default:
+			s.dropped.Add(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.commits <- commit:
+				return
+			default:
+			}
+
+			//The unbuffered channel has nothing to discard, so the commit itself is dropped
+			select {
+			case <-s.commits:
+				s.dropped.Add(1)
+			default:
+				s.dropped.Add(1)
+				return
+			}
+		}
+	default:
+		var done <-chan struct{}
+		if ctx != nil {
+			done = ctx.Done()
+		}
+
+		select {
+		case s.commits <- commit:
+		case <-s.done:
+		case <-done:
+			s.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package repository_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/repository"
+)
+
+func TestPublisher(t *testing.T) {
+	testRepository(t, func(t *testing.T) repository.Repository {
+		return repository.NewPublisher(repository.NewMemoryRepository())
+	})
+}
+
+func TestPublisher_Listen(t *testing.T) {
+	p := repository.NewPublisher(repository.NewMemoryRepository())
+	ctx := context.Background()
+
+	got := []int64{}
+	cancel := p.Listen(func(ctx context.Context, commit repository.Commit) {
+		got = append(got, commit.Sequence)
+
+		//The commit is persisted before it is published
+		commits, err := p.Commits(ctx, commit.ObjectType, commit.ObjectID)
+		if err != nil || len(commits) == 0 || commits[len(commits)-1].Sequence != commit.Sequence {
+			t.Errorf("Listener is called before the commit %d is persisted", commit.Sequence)
+		}
+	})
+
+	objects := []interface{}{
+		person{ID: 1, Name: "test1"},
+		person{ID: 1, Name: "test1"},
+		person{ID: 1, Name: "test2"},
+		withoutID{Name: "test3"},
+	}
+	for _, obj := range objects {
+		p.Commit(ctx, obj)
+	}
+
+	cancel()
+	if _, err := p.Commit(ctx, person{ID: 1, Name: "test4"}); err != nil {
+		t.Fatalf("Publisher.Commit() error = %v", err)
+	}
+
+	if want := []int64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Listener got = %v, want %v", got, want)
+	}
+}
+
+func TestPublisher_Subscribe(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		backPressure repository.BackPressure
+		want         []int64
+		wantDropped  int64
+	}{
+		{"drop the newest commits", 2, repository.DropNewest, []int64{1, 2}, 2},
+		{"drop the oldest commits", 2, repository.DropOldest, []int64{3, 4}, 2},
+		{"drop the commits without buffer", 0, repository.DropOldest, []int64{}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := repository.NewPublisher(repository.NewMemoryRepository())
+			s := p.Subscribe(tt.size, tt.backPressure)
+
+			for i := 1; i <= 4; i++ {
+				if _, err := p.Commit(context.Background(), person{ID: i}); err != nil {
+					t.Fatalf("Publisher.Commit() error = %v", err)
+				}
+			}
+			s.Close()
+
+			got := []int64{}
+			for commit := range s.C() {
+				got = append(got, commit.Sequence)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Subscription.C() got = %v, want %v", got, tt.want)
+			}
+			if s.Dropped() != tt.wantDropped {
+				t.Errorf("Subscription.Dropped() = %v, want %v", s.Dropped(), tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestPublisher_SubscribeBlock(t *testing.T) {
+	p := repository.NewPublisher(repository.NewMemoryRepository())
+	s := p.Subscribe(1, repository.Block)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 3; i++ {
+			if _, err := p.Commit(context.Background(), person{ID: i}); err != nil {
+				t.Errorf("Publisher.Commit() error = %v", err)
+			}
+		}
+	}()
+
+	got := []int64{}
+	for i := 0; i < 3; i++ {
+		got = append(got, (<-s.C()).Sequence)
+	}
+	<-done
+
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Subscription.C() got = %v, want %v", got, want)
+	}
+
+	//The blocked commit gives up when its context is done
+	p.Commit(context.Background(), person{ID: 4})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Commit(ctx, person{ID: 5}); err != nil {
+		t.Fatalf("Publisher.Commit() error = %v", err)
+	}
+	if s.Dropped() != 1 {
+		t.Errorf("Subscription.Dropped() = %v, want 1", s.Dropped())
+	}
+
+	//The blocked commit gives up when the subscription is closed
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Close()
+	}()
+	if _, err := p.Commit(context.Background(), person{ID: 6}); err != nil {
+		t.Fatalf("Publisher.Commit() error = %v", err)
+	}
+	s.Close()
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//Query filters the recorded changes. The zero values of the fields are not used as filters
+type Query struct {
+	ObjectType string
+	ObjectID   string
+
+	//Path is a path.Match pattern on the diff field, where `*` matches a single segment, e.g. `Address.*`.
+	//The dots within a segment are escaped by a backslash, e.g. `Labels.app\.kubernetes\.io`
+	Path string
+
+	Author string
+
+	//From and To limit the commit timestamp within [From, To)
+	From time.Time
+	To   time.Time
+
+	ChangeTypes []diff.ChangeType
+
+	Offset int
+	Limit  int
+
+	//OldestFirst orders the changes by the oldest commit, the default is newest first
+	OldestFirst bool
+}
+
+//Change is a recorded diff enriched with the metadata of its commit
+type Change struct {
+	diff.Diff
+	Sequence   int64             `json:"sequence"`
+	Version    int64             `json:"version"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Author     string            `json:"author,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+func (q Query) matchObject(objectType, objectID string) bool {
+	return (q.ObjectType == "" || q.ObjectType == objectType) &&
+		(q.ObjectID == "" || q.ObjectID == objectID)
+}
+
+func (q Query) matchCommit(c Commit) bool {
+	return q.matchObject(c.ObjectType, c.ObjectID) &&
+		(q.Author == "" || q.Author == c.Author) &&
+		(q.From.IsZero() || !c.Timestamp.Before(q.From)) &&
+		(q.To.IsZero() || c.Timestamp.Before(q.To))
+}
+
+func (q Query) matchDiff(d diff.Diff) bool {
+	if len(q.ChangeTypes) > 0 {
+		found := false
+		for _, changeType := range q.ChangeTypes {
+			if changeType == d.ChangeType {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if q.Path == "" {
+		return true
+	}
+
+	matched, err := diff.MatchField(q.Path, d.Field)
+	return err == nil && matched
+}
+
+//collect returns the matched changes of the commits, which should be ordered by sequence
+func (q Query) collect(commits []Commit) []Change {
+	changes := []Change{}
+	for _, c := range commits {
+		if !q.matchCommit(c) {
+			continue
+		}
+
+		for _, d := range c.Diffs {
+			if q.matchDiff(d) {
+				changes = append(changes, Change{
+					Diff:       d,
+					Sequence:   c.Sequence,
+					Version:    c.Version,
+					Timestamp:  c.Timestamp,
+					Author:     c.Author,
+					Properties: c.Properties,
+				})
+			}
+		}
+	}
+
+	return q.page(changes)
+}
+
+//page orders the changes, which are ordered by the oldest commit, and applies the offset and limit
+func (q Query) page(changes []Change) []Change {
+	if !q.OldestFirst {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Sequence > changes[j].Sequence
+		})
+	}
+
+	if q.Offset >= len(changes) {
+		return []Change{}
+	}
+	if q.Offset > 0 {
+		changes = changes[q.Offset:]
+	}
+
+	if q.Limit > 0 && q.Limit < len(changes) {
+		changes = changes[:q.Limit]
+	}
+
+	return changes
+}
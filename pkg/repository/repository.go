@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//Commit represents a recorded change of an object
+type Commit struct {
+	Sequence   int64             `json:"sequence"`
+	ObjectType string            `json:"object_type"`
+	ObjectID   string            `json:"object_id"`
+	Version    int64             `json:"version"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Author     string            `json:"author,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Diffs      []diff.Diff       `json:"diffs"`
+}
+
+//Snapshot represents the JSON encoded state of an object on a version
+type Snapshot struct {
+	ObjectType string          `json:"object_type"`
+	ObjectID   string          `json:"object_id"`
+	Version    int64           `json:"version"`
+	Sequence   int64           `json:"sequence"`
+	Timestamp  time.Time       `json:"timestamp"`
+	State      json.RawMessage `json:"state"`
+}
+
+//Repository stores the snapshots of the objects along with their commit history
+type Repository interface {
+	//Commit takes a snapshot of the object under its ObjectType and ObjectID and records the differences
+	//against the previous snapshot. It returns nil when the object has no changes.
+	//The author and properties of the commit are taken from libra.WithAuthor and libra.WithCommitProperties
+	Commit(ctx context.Context, obj interface{}) (*Commit, error)
+
+	//Commits returns the commits of an object ordered by version
+	Commits(ctx context.Context, objectType, objectID string) ([]Commit, error)
+
+	//Snapshots returns the snapshots of an object ordered by version
+	Snapshots(ctx context.Context, objectType, objectID string) ([]Snapshot, error)
+
+	//FindChanges returns the recorded changes which match the query
+	FindChanges(ctx context.Context, q Query) ([]Change, error)
+}
+
+//change is the result of comparing an object against its previous snapshot
+type change struct {
+	ObjectType string
+	ObjectID   string
+	State      json.RawMessage
+	Diffs      []diff.Diff
+}
+
+//newCommit returns the commit of the change along with the metadata which is carried by the context
+func newCommit(ctx context.Context, c *change, sequence, version int64) Commit {
+	properties := libra.CommitPropertiesFromContext(ctx)
+	if len(properties) == 0 {
+		properties = nil
+	}
+
+	return Commit{
+		Sequence:   sequence,
+		ObjectType: c.ObjectType,
+		ObjectID:   c.ObjectID,
+		Version:    version,
+		Timestamp:  time.Now().UTC(),
+		Author:     libra.AuthorFromContext(ctx),
+		Properties: properties,
+		Diffs:      c.Diffs,
+	}
+}
+
+//objectKey identifies an object inside of a repository
+type objectKey struct {
+	ObjectType string
+	ObjectID   string
+}
+
+//identify returns the struct value of the object along with its ObjectType and ObjectID
+func identify(ctx context.Context, obj interface{}) (reflect.Value, objectKey, error) {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, objectKey{}, fmt.Errorf("object cannot be nil")
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, objectKey{}, fmt.Errorf("object should be a struct")
+	}
+
+	objectID, err := diff.GetObjectID(ctx, v)
+	if err != nil {
+		return reflect.Value{}, objectKey{}, err
+	}
+
+	if objectID == "" {
+		return reflect.Value{}, objectKey{}, fmt.Errorf("object should have a field with tag `id`")
+	}
+
+	return v, objectKey{ObjectType: v.Type().String(), ObjectID: objectID}, nil
+}
+
+//compareSnapshot compares the object against the previous snapshot, which is nil for the first commit.
+//The object is compared as it is decoded from its snapshot, so the fields which are not encoded are not recorded
+//and the values of the interface fields have the same types as the previous snapshot
+func compareSnapshot(ctx context.Context, v reflect.Value, key objectKey, previous *Snapshot) (*change, error) {
+	state, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("error on encode snapshot Error : %s", err.Error())
+	}
+
+	current := reflect.New(v.Type())
+	if err := json.Unmarshal(state, current.Interface()); err != nil {
+		return nil, fmt.Errorf("error on decode snapshot Error : %s", err.Error())
+	}
+
+	var diffs []diff.Diff
+	if previous == nil {
+		diffs, err = libra.Compare(ctx, nil, current.Elem().Interface())
+	} else {
+		old := reflect.New(v.Type())
+		if err := json.Unmarshal(previous.State, old.Interface()); err != nil {
+			return nil, fmt.Errorf("error on decode snapshot Error : %s", err.Error())
+		}
+		diffs, err = libra.Compare(ctx, old.Elem().Interface(), current.Elem().Interface())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(diffs) == 0 {
+		return nil, nil
+	}
+
+	for i := range diffs {
+		diffs[i].ObjectID = key.ObjectID
+	}
+
+	return &change{
+		ObjectType: key.ObjectType,
+		ObjectID:   key.ObjectID,
+		State:      state,
+		Diffs:      diffs,
+	}, nil
+}
+
+//decodeValue decodes a stored value with the registry. The value of a type which is not registered,
+//e.g. after the repository is reopened, is decoded into the generic JSON value so the history is still readable
+func decodeValue(tv *codec.TypedValue, registry *codec.Registry) (interface{}, error) {
+	v, err := codec.DecodeValue(tv, registry)
+	unregistered := &codec.UnregisteredTypeError{}
+	if err == nil || !errors.As(err, &unregistered) {
+		return v, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(tv.Value, &generic); err != nil {
+		return nil, fmt.Errorf("error on decode %s Error : %s", tv.Type, err.Error())
+	}
+
+	return generic, nil
+}
+
+//decodeTyped converts the stored diff back into diff.Diff, see decodeValue
+func decodeTyped(td codec.TypedDiff, registry *codec.Registry) (diff.Diff, error) {
+	oldValue, err := decodeValue(td.Old, registry)
+	if err != nil {
+		return diff.Diff{}, fmt.Errorf("error on decode old value of %s Error : %s", td.Field, err.Error())
+	}
+
+	newValue, err := decodeValue(td.New, registry)
+	if err != nil {
+		return diff.Diff{}, fmt.Errorf("error on decode new value of %s Error : %s", td.Field, err.Error())
+	}
+
+	return diff.Diff{
+		ChangeType: td.ChangeType,
+		ObjectType: td.ObjectType,
+		ObjectID:   td.ObjectID,
+		Field:      td.Field,
+		Old:        oldValue,
+		New:        newValue,
+	}, nil
+}
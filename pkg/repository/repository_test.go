@@ -0,0 +1,524 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/repository"
+)
+
+type person struct {
+	ID      int `libra:"id"`
+	Name    string
+	Age     int
+	Address address
+}
+
+type address struct {
+	Street string
+	City   string
+}
+
+type withoutID struct {
+	Name string
+}
+
+type account struct {
+	ID     int    `libra:"id"`
+	Secret string `json:"-"`
+	Extra  interface{}
+}
+
+//testRepository runs the common behaviour tests against a Repository implementation
+func testRepository(t *testing.T, newRepository func(t *testing.T) repository.Repository) {
+	t.Run("commit the object history", func(t *testing.T) {
+		repo := newRepository(t)
+		ctx := context.Background()
+
+		first, err := repo.Commit(ctx, person{ID: 1, Name: "test1", Age: 22})
+		if err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+		wantFirst := []diff.Diff{{
+			ChangeType: diff.New,
+			ObjectType: "repository_test.person",
+			ObjectID:   "1",
+			New:        person{ID: 1, Name: "test1", Age: 22},
+		}}
+		if first.Sequence != 1 || first.Version != 1 || !reflect.DeepEqual(first.Diffs, wantFirst) {
+			t.Errorf("Repository.Commit() = %+v, want version 1 with diffs %v", first, wantFirst)
+		}
+
+		if _, err := repo.Commit(ctx, &person{ID: 2, Name: "other"}); err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+
+		second, err := repo.Commit(ctx, &person{ID: 1, Name: "test2", Age: 22, Address: address{City: "Malang"}})
+		if err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+		wantSecond := []diff.Diff{{
+			ChangeType: diff.Changed,
+			ObjectType: "repository_test.person",
+			ObjectID:   "1",
+			Field:      "Name",
+			Old:        "test1",
+			New:        "test2",
+		}, {
+			ChangeType: diff.Changed,
+			ObjectType: "repository_test.person",
+			ObjectID:   "1",
+			Field:      "Address.City",
+			Old:        "",
+			New:        "Malang",
+		}}
+		if second.Sequence != 3 || second.Version != 2 || !reflect.DeepEqual(second.Diffs, wantSecond) {
+			t.Errorf("Repository.Commit() = %+v, want version 2 with diffs %v", second, wantSecond)
+		}
+
+		unchanged, err := repo.Commit(ctx, person{ID: 1, Name: "test2", Age: 22, Address: address{City: "Malang"}})
+		if err != nil || unchanged != nil {
+			t.Errorf("Repository.Commit() = %v, %v, want nil commit without changes", unchanged, err)
+		}
+
+		commits, err := repo.Commits(ctx, "repository_test.person", "1")
+		if err != nil {
+			t.Fatalf("Repository.Commits() error = %v", err)
+		}
+		if len(commits) != 2 || commits[0].Version != 1 || commits[1].Version != 2 || !reflect.DeepEqual(commits[1].Diffs, wantSecond) {
+			t.Errorf("Repository.Commits() = %+v, want 2 commits", commits)
+		}
+
+		snapshots, err := repo.Snapshots(ctx, "repository_test.person", "1")
+		if err != nil {
+			t.Fatalf("Repository.Snapshots() error = %v", err)
+		}
+		if len(snapshots) != 2 {
+			t.Fatalf("Repository.Snapshots() = %+v, want 2 snapshots", snapshots)
+		}
+
+		got := person{}
+		if err := json.Unmarshal(snapshots[1].State, &got); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		want := person{ID: 1, Name: "test2", Age: 22, Address: address{City: "Malang"}}
+		if snapshots[1].Version != 2 || snapshots[1].Sequence != 3 || !reflect.DeepEqual(got, want) {
+			t.Errorf("Repository.Snapshots() = %+v, want %v", snapshots[1], want)
+		}
+
+		empty, err := repo.Commits(ctx, "repository_test.person", "404")
+		if err != nil || len(empty) != 0 {
+			t.Errorf("Repository.Commits() = %v, %v, want empty commits", empty, err)
+		}
+	})
+
+	t.Run("find the changes", func(t *testing.T) {
+		repo := newRepository(t)
+		ctx := context.Background()
+		start := time.Now().Add(-time.Minute)
+
+		objects := []interface{}{
+			person{ID: 1, Name: "test1"},
+			person{ID: 2, Name: "other"},
+			person{ID: 1, Name: "test2", Address: address{Street: "jalan 123", City: "Malang"}},
+			person{ID: 1, Name: "test2", Age: 23, Address: address{Street: "jalan ABC", City: "Malang"}},
+		}
+		for _, obj := range objects {
+			if _, err := repo.Commit(ctx, obj); err != nil {
+				t.Fatalf("Repository.Commit() error = %v", err)
+			}
+		}
+
+		type change struct {
+			Sequence int64
+			Field    string
+		}
+		tests := []struct {
+			name  string
+			query repository.Query
+			want  []change
+		}{
+			{
+				"newest first by object",
+				repository.Query{ObjectType: "repository_test.person", ObjectID: "1"},
+				[]change{{4, "Age"}, {4, "Address.Street"}, {3, "Name"}, {3, "Address.Street"}, {3, "Address.City"}, {1, ""}},
+			}, {
+				"oldest first by object type",
+				repository.Query{ObjectType: "repository_test.person", OldestFirst: true},
+				[]change{{1, ""}, {2, ""}, {3, "Name"}, {3, "Address.Street"}, {3, "Address.City"}, {4, "Age"}, {4, "Address.Street"}},
+			}, {
+				"by path pattern",
+				repository.Query{Path: "Address.*"},
+				[]change{{4, "Address.Street"}, {3, "Address.Street"}, {3, "Address.City"}},
+			}, {
+				"by path pattern with offset and limit",
+				repository.Query{Path: "Address.*", Offset: 1, Limit: 1},
+				[]change{{3, "Address.Street"}},
+			}, {
+				"by path pattern with character class",
+				repository.Query{Path: "Address.[CS]it?", OldestFirst: true},
+				[]change{{3, "Address.City"}},
+			}, {
+				"by case-sensitive path",
+				repository.Query{Path: "address.*"},
+				[]change{},
+			}, {
+				"by change type",
+				repository.Query{ChangeTypes: []diff.ChangeType{diff.New}},
+				[]change{{2, ""}, {1, ""}},
+			}, {
+				"by change types with limit",
+				repository.Query{ChangeTypes: []diff.ChangeType{diff.New, diff.Changed}, OldestFirst: true, Limit: 3},
+				[]change{{1, ""}, {2, ""}, {3, "Name"}},
+			}, {
+				"by time range",
+				repository.Query{From: start, To: time.Now().Add(time.Minute), ObjectID: "2"},
+				[]change{{2, ""}},
+			}, {
+				"by time range before the commits",
+				repository.Query{To: start},
+				[]change{},
+			}, {
+				"by author",
+				repository.Query{Author: "nobody"},
+				[]change{},
+			}, {
+				"with offset and limit",
+				repository.Query{Offset: 1, Limit: 2},
+				[]change{{4, "Address.Street"}, {3, "Name"}},
+			}, {
+				"with offset beyond the changes",
+				repository.Query{Offset: 10},
+				[]change{},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				changes, err := repo.FindChanges(ctx, tt.query)
+				if err != nil {
+					t.Fatalf("Repository.FindChanges() error = %v", err)
+				}
+
+				got := []change{}
+				for _, c := range changes {
+					got = append(got, change{Sequence: c.Sequence, Field: c.Field})
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Repository.FindChanges() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		changes, err := repo.FindChanges(ctx, repository.Query{ObjectID: "1", Path: "Name"})
+		if err != nil {
+			t.Fatalf("Repository.FindChanges() error = %v", err)
+		}
+		want := repository.Change{
+			Diff: diff.Diff{
+				ChangeType: diff.Changed,
+				ObjectType: "repository_test.person",
+				ObjectID:   "1",
+				Field:      "Name",
+				Old:        "test1",
+				New:        "test2",
+			},
+			Sequence:  3,
+			Version:   2,
+			Timestamp: changes[0].Timestamp,
+		}
+		if len(changes) != 1 || !reflect.DeepEqual(changes[0], want) || changes[0].Timestamp.IsZero() {
+			t.Errorf("Repository.FindChanges() = %+v, want %+v", changes, want)
+		}
+	})
+
+	t.Run("record the commit metadata from context", func(t *testing.T) {
+		repo := newRepository(t)
+		ctx := libra.WithAuthor(context.Background(), "alice")
+		ctx = libra.WithCommitProperties(ctx, map[string]string{"request_id": "req-1"})
+
+		commit, err := repo.Commit(ctx, person{ID: 1, Name: "test1"})
+		if err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+		wantProperties := map[string]string{"request_id": "req-1"}
+		if commit.Author != "alice" || !reflect.DeepEqual(commit.Properties, wantProperties) {
+			t.Errorf("Repository.Commit() = %+v, want author alice with properties %v", commit, wantProperties)
+		}
+
+		if _, err := repo.Commit(context.Background(), person{ID: 1, Name: "test2"}); err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+
+		commits, err := repo.Commits(ctx, "repository_test.person", "1")
+		if err != nil {
+			t.Fatalf("Repository.Commits() error = %v", err)
+		}
+		if len(commits) != 2 ||
+			commits[0].Author != "alice" || !reflect.DeepEqual(commits[0].Properties, wantProperties) ||
+			commits[1].Author != "" || commits[1].Properties != nil {
+			t.Errorf("Repository.Commits() = %+v, want the metadata on the first commit only", commits)
+		}
+
+		changes, err := repo.FindChanges(ctx, repository.Query{Author: "alice"})
+		if err != nil {
+			t.Fatalf("Repository.FindChanges() error = %v", err)
+		}
+		if len(changes) != 1 || changes[0].Sequence != 1 || !reflect.DeepEqual(changes[0].Properties, wantProperties) {
+			t.Errorf("Repository.FindChanges() = %+v, want the change of the first commit", changes)
+		}
+	})
+
+	t.Run("reconstruct the shadows", func(t *testing.T) {
+		repo := newRepository(t)
+		ctx := context.Background()
+
+		versions := []person{
+			{ID: 1, Name: "test1", Age: 22},
+			{ID: 1, Name: "test2", Age: 22, Address: address{City: "Malang"}},
+			{ID: 1, Name: "test2", Age: 23},
+		}
+		times := []time.Time{}
+		for _, obj := range versions {
+			commit, err := repo.Commit(ctx, obj)
+			if err != nil {
+				t.Fatalf("Repository.Commit() error = %v", err)
+			}
+			times = append(times, commit.Timestamp)
+		}
+
+		for i, want := range versions {
+			got := person{Name: "leftover"}
+			if err := repository.Shadow(ctx, repo, &got, "1", int64(i+1)); err != nil {
+				t.Fatalf("Shadow() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Shadow() = %v, want %v", got, want)
+			}
+
+			got = person{}
+			if err := repository.ShadowAt(ctx, repo, &got, "1", times[i]); err != nil {
+				t.Fatalf("ShadowAt() error = %v", err)
+			}
+			expected := want
+			for j := range times {
+				if !times[j].After(times[i]) {
+					expected = versions[j]
+				}
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("ShadowAt() = %v, want %v", got, expected)
+			}
+		}
+
+		latest := person{}
+		if err := repository.ShadowAt(ctx, repo, &latest, "1", time.Now().Add(time.Hour)); err != nil || !reflect.DeepEqual(latest, versions[2]) {
+			t.Errorf("ShadowAt() = %v, %v, want %v", latest, err, versions[2])
+		}
+
+		if err := repository.Shadow(ctx, repo, &person{}, "1", 4); err != repository.ErrSnapshotNotFound {
+			t.Errorf("Shadow() error = %v, want ErrSnapshotNotFound", err)
+		}
+		if err := repository.ShadowAt(ctx, repo, &person{}, "1", times[0].Add(-time.Hour)); err != repository.ErrSnapshotNotFound {
+			t.Errorf("ShadowAt() error = %v, want ErrSnapshotNotFound", err)
+		}
+		if err := repository.Shadow(ctx, repo, person{}, "1", 1); err == nil {
+			t.Errorf("Shadow() error = nil, wantErr true")
+		}
+	})
+
+	t.Run("compact the history", func(t *testing.T) {
+		repo := newRepository(t)
+		compactor, ok := repo.(repository.Compactor)
+		if !ok {
+			t.Skip("repository is not a Compactor")
+		}
+		ctx := context.Background()
+
+		objects := []interface{}{
+			person{ID: 1, Name: "test1"},
+			person{ID: 1, Name: "test2"},
+			person{ID: 2, Name: "other"},
+			person{ID: 1, Name: "test3", Address: address{City: "Malang"}},
+			person{ID: 1, Name: "test4", Address: address{City: "Malang"}},
+		}
+		for _, obj := range objects {
+			if _, err := repo.Commit(ctx, obj); err != nil {
+				t.Fatalf("Repository.Commit() error = %v", err)
+			}
+		}
+
+		tests := []struct {
+			name        string
+			policy      repository.RetentionPolicy
+			wantRemoved int
+			want        []int64
+		}{
+			{"keep the versions within a duration", repository.KeepWithin(time.Hour), 0, []int64{1, 2, 3, 4}},
+			{"keep the last versions", repository.KeepLast(2), 2, []int64{3, 4}},
+			{"always keep the latest version", repository.KeepWithin(-time.Hour), 1, []int64{4}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				removed, err := compactor.Compact(ctx, tt.policy)
+				if err != nil || removed != tt.wantRemoved {
+					t.Fatalf("Compactor.Compact() = %v, %v, want %v", removed, err, tt.wantRemoved)
+				}
+
+				commits, err := repo.Commits(ctx, "repository_test.person", "1")
+				if err != nil {
+					t.Fatalf("Repository.Commits() error = %v", err)
+				}
+				snapshots, err := repo.Snapshots(ctx, "repository_test.person", "1")
+				if err != nil {
+					t.Fatalf("Repository.Snapshots() error = %v", err)
+				}
+
+				got := []int64{}
+				for i, commit := range commits {
+					got = append(got, commit.Version)
+					if i >= len(snapshots) || snapshots[i].Version != commit.Version {
+						t.Errorf("Repository.Snapshots() = %v, want a snapshot of version %d", snapshots, commit.Version)
+					}
+				}
+				if !reflect.DeepEqual(got, tt.want) || len(snapshots) != len(commits) {
+					t.Errorf("Repository.Commits() versions = %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		other, err := repo.Commits(ctx, "repository_test.person", "2")
+		if err != nil || len(other) != 1 {
+			t.Errorf("Repository.Commits() = %v, %v, want the only version of the other object", other, err)
+		}
+
+		shadow := person{}
+		if err := repository.Shadow(ctx, repo, &shadow, "1", 4); err != nil || !reflect.DeepEqual(shadow, objects[4]) {
+			t.Errorf("Shadow() = %v, %v, want %v", shadow, err, objects[4])
+		}
+		if err := repository.Shadow(ctx, repo, &person{}, "1", 3); err != repository.ErrSnapshotNotFound {
+			t.Errorf("Shadow() error = %v, want ErrSnapshotNotFound", err)
+		}
+
+		for _, obj := range []interface{}{person{ID: 1, Name: "test5"}, person{ID: 1, Name: "test6", Age: 20}} {
+			if _, err := repo.Commit(ctx, obj); err != nil {
+				t.Fatalf("Repository.Commit() error = %v", err)
+			}
+		}
+
+		removed, err := compactor.Compact(ctx, repository.SquashOlderThan(-time.Hour))
+		if err != nil || removed != 2 {
+			t.Fatalf("Compactor.Compact() = %v, %v, want 2", removed, err)
+		}
+
+		commits, err := repo.Commits(ctx, "repository_test.person", "1")
+		if err != nil {
+			t.Fatalf("Repository.Commits() error = %v", err)
+		}
+		want := []diff.Diff{{
+			ChangeType: diff.Changed,
+			ObjectType: "repository_test.person",
+			ObjectID:   "1",
+			Field:      "Name",
+			Old:        "test3",
+			New:        "test6",
+		}, {
+			ChangeType: diff.Changed,
+			ObjectType: "repository_test.person",
+			ObjectID:   "1",
+			Field:      "Address.City",
+			Old:        "Malang",
+			New:        "",
+		}, {
+			ChangeType: diff.Changed,
+			ObjectType: "repository_test.person",
+			ObjectID:   "1",
+			Field:      "Age",
+			Old:        0,
+			New:        20,
+		}}
+		if len(commits) != 1 || commits[0].Version != 6 || !reflect.DeepEqual(commits[0].Diffs, want) {
+			t.Errorf("Repository.Commits() = %+v, want the squashed version 6 with diffs %v", commits, want)
+		}
+	})
+
+	t.Run("compare the object as its snapshot", func(t *testing.T) {
+		repo := newRepository(t)
+		ctx := context.Background()
+
+		first, err := repo.Commit(ctx, account{ID: 1, Secret: "s", Extra: 1})
+		if err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+		wantFirst := []diff.Diff{{
+			ChangeType: diff.New,
+			ObjectType: "repository_test.account",
+			ObjectID:   "1",
+			New:        account{ID: 1, Extra: float64(1)},
+		}}
+		if !reflect.DeepEqual(first.Diffs, wantFirst) {
+			t.Errorf("Repository.Commit() = %v, want %v", first.Diffs, wantFirst)
+		}
+
+		unchanged, err := repo.Commit(ctx, account{ID: 1, Secret: "t", Extra: 1})
+		if err != nil || unchanged != nil {
+			t.Errorf("Repository.Commit() = %v, %v, want nil, nil", unchanged, err)
+		}
+
+		second, err := repo.Commit(ctx, account{ID: 1, Secret: "t", Extra: 2})
+		if err != nil {
+			t.Fatalf("Repository.Commit() error = %v", err)
+		}
+		wantSecond := []diff.Diff{{
+			ChangeType: diff.Changed,
+			ObjectType: "repository_test.account",
+			ObjectID:   "1",
+			Field:      "Extra",
+			Old:        float64(1),
+			New:        float64(2),
+		}}
+		if !reflect.DeepEqual(second.Diffs, wantSecond) {
+			t.Errorf("Repository.Commit() = %v, want %v", second.Diffs, wantSecond)
+		}
+	})
+
+	t.Run("failed when commit invalid objects", func(t *testing.T) {
+		repo := newRepository(t)
+		invalids := []interface{}{nil, (*person)(nil), "foo", withoutID{Name: "test1"}}
+		for _, obj := range invalids {
+			if _, err := repo.Commit(context.Background(), obj); err == nil {
+				t.Errorf("Repository.Commit(%v) error = nil, wantErr true", obj)
+			}
+		}
+	})
+
+	t.Run("commit concurrently", func(t *testing.T) {
+		repo := newRepository(t)
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(age int) {
+				defer wg.Done()
+				if _, err := repo.Commit(context.Background(), person{ID: 1, Age: age}); err != nil {
+					t.Errorf("Repository.Commit() error = %v", err)
+				}
+			}(i + 1)
+		}
+		wg.Wait()
+
+		commits, err := repo.Commits(context.Background(), "repository_test.person", "1")
+		if err != nil {
+			t.Fatalf("Repository.Commits() error = %v", err)
+		}
+		for i, c := range commits {
+			if c.Version != int64(i+1) {
+				t.Errorf("Repository.Commits()[%d].Version = %d, want %d", i, c.Version, i+1)
+			}
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+)
+
+//RetentionPolicy returns the retained commits of an object, the commits are ordered by version.
+//A policy could only drop the commits or replace the diffs of a retained commit, e.g. to squash the older commits into it.
+//The snapshots of the retained commits are kept, so the shadows of the retained versions could still be reconstructed
+type RetentionPolicy func(ctx context.Context, now time.Time, commits []Commit) ([]Commit, error)
+
+//Compactor is implemented by the repositories which could remove their history according to a RetentionPolicy
+type Compactor interface {
+	//Compact applies the policy on the history of every object and returns the number of the removed commits.
+	//The latest version of an object is always retained
+	Compact(ctx context.Context, policy RetentionPolicy) (int, error)
+}
+
+//KeepLast retains the last n versions of each object
+func KeepLast(n int) RetentionPolicy {
+	return func(ctx context.Context, now time.Time, commits []Commit) ([]Commit, error) {
+		keep := n
+		if keep < 1 {
+			keep = 1
+		}
+
+		if len(commits) <= keep {
+			return commits, nil
+		}
+
+		return commits[len(commits)-keep:], nil
+	}
+}
+
+//KeepWithin retains the versions which are committed within the duration
+func KeepWithin(d time.Duration) RetentionPolicy {
+	return func(ctx context.Context, now time.Time, commits []Commit) ([]Commit, error) {
+		cutoff := now.Add(-d)
+		for i, commit := range commits {
+			if !commit.Timestamp.Before(cutoff) {
+				return commits[i:], nil
+			}
+		}
+
+		return []Commit{}, nil
+	}
+}
+
+//SquashOlderThan squashes the versions which are committed before the duration into the latest of them,
+//its diffs are composed from the squashed commits
+func SquashOlderThan(d time.Duration) RetentionPolicy {
+	return func(ctx context.Context, now time.Time, commits []Commit) ([]Commit, error) {
+		cutoff := now.Add(-d)
+		older := 0
+		for older < len(commits) && commits[older].Timestamp.Before(cutoff) {
+			older++
+		}
+
+		if older < 2 {
+			return commits, nil
+		}
+
+		sets := make([][]diff.Diff, 0, older)
+		for _, commit := range commits[:older] {
+			sets = append(sets, commit.Diffs)
+		}
+
+		diffs, err := patcher.Compose(ctx, sets...)
+		if err != nil {
+			return nil, err
+		}
+
+		squashed := commits[older-1]
+		squashed.Diffs = diffs
+
+		return append([]Commit{squashed}, commits[older:]...), nil
+	}
+}
+
+//RunCompaction compacts the repository on every interval until the context is done.
+//The errors are passed to onError, which could be nil, and the next compaction is still run
+func RunCompaction(ctx context.Context, c Compactor, policy RetentionPolicy, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := c.Compact(ctx, policy); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+//retain applies the policy on the commits of an object. It collects the retained commits by sequence
+//and the sequences of the retained commits which diffs are replaced
+func retain(ctx context.Context, policy RetentionPolicy, now time.Time, commits []Commit, retained map[int64]Commit, replaced map[int64]bool) error {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	kept, err := policy(ctx, now, commits)
+	if err != nil {
+		return fmt.Errorf("error on retain %s#%s Error : %s", commits[0].ObjectType, commits[0].ObjectID, err.Error())
+	}
+
+	original := make(map[int64]Commit, len(commits))
+	for _, commit := range commits {
+		original[commit.Sequence] = commit
+	}
+
+	for _, commit := range kept {
+		o, ok := original[commit.Sequence]
+		if !ok {
+			return fmt.Errorf("error on retain %s#%s Error : commit %d is not found", commits[0].ObjectType, commits[0].ObjectID, commit.Sequence)
+		}
+
+		if !reflect.DeepEqual(o.Diffs, commit.Diffs) {
+			o.Diffs = commit.Diffs
+			replaced[o.Sequence] = true
+		}
+		retained[o.Sequence] = o
+	}
+
+	latest := commits[len(commits)-1]
+	if _, ok := retained[latest.Sequence]; !ok {
+		retained[latest.Sequence] = latest
+	}
+
+	return nil
+}
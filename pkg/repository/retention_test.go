@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/repository"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	now := time.Date(2020, time.May, 4, 0, 0, 0, 0, time.UTC)
+	commits := []repository.Commit{
+		{Sequence: 1, Version: 1, Timestamp: now.Add(-3 * time.Hour), Diffs: []diff.Diff{{ChangeType: diff.Changed, Field: "Name", Old: "a", New: "b"}}},
+		{Sequence: 2, Version: 2, Timestamp: now.Add(-2 * time.Hour), Diffs: []diff.Diff{{ChangeType: diff.Changed, Field: "Name", Old: "b", New: "c"}}},
+		{Sequence: 3, Version: 3, Timestamp: now.Add(-time.Hour), Diffs: []diff.Diff{{ChangeType: diff.Changed, Field: "Age", Old: 1, New: 2}}},
+	}
+
+	tests := []struct {
+		name    string
+		policy  repository.RetentionPolicy
+		want    []repository.Commit
+		wantErr bool
+	}{
+		{"keep the last versions", repository.KeepLast(2), commits[1:], false},
+		{"keep at least one version", repository.KeepLast(0), commits[2:], false},
+		{"keep all of the versions", repository.KeepLast(5), commits, false},
+		{"keep the versions within a duration", repository.KeepWithin(150 * time.Minute), commits[1:], false},
+		{"keep none of the versions", repository.KeepWithin(time.Minute), []repository.Commit{}, false},
+		{
+			"squash the older versions",
+			repository.SquashOlderThan(90 * time.Minute),
+			[]repository.Commit{
+				{Sequence: 2, Version: 2, Timestamp: commits[1].Timestamp, Diffs: []diff.Diff{{ChangeType: diff.Changed, Field: "Name", Old: "a", New: "c"}}},
+				commits[2],
+			},
+			false,
+		},
+		{"squash a single version", repository.SquashOlderThan(150 * time.Minute), commits, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy(context.Background(), now, commits)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RetentionPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RetentionPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCompaction(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for _, name := range []string{"test1", "test2", "test3"} {
+		if _, err := repo.Commit(ctx, person{ID: 1, Name: name}); err != nil {
+			t.Fatalf("MemoryRepository.Commit() error = %v", err)
+		}
+	}
+
+	var runs int32
+	policy := func(ctx context.Context, now time.Time, commits []repository.Commit) ([]repository.Commit, error) {
+		if atomic.AddInt32(&runs, 1) >= 2 {
+			cancel()
+		}
+		return repository.KeepLast(1)(ctx, now, commits)
+	}
+
+	if err := repository.RunCompaction(ctx, repo, policy, time.Millisecond, nil); err != context.Canceled {
+		t.Errorf("RunCompaction() error = %v, want context.Canceled", err)
+	}
+
+	commits, err := repo.Commits(context.Background(), "repository_test.person", "1")
+	if err != nil || len(commits) != 1 || commits[0].Version != 3 {
+		t.Errorf("MemoryRepository.Commits() = %+v, %v, want the latest version only", commits, err)
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+//ErrSnapshotNotFound is returned when the object has no snapshot on the requested version or time
+var ErrSnapshotNotFound = errors.New("snapshot is not found")
+
+//Shadow fills the target, which should be a pointer to the struct of the object, with its state on the version
+func Shadow(ctx context.Context, repo Repository, target interface{}, objectID string, version int64) error {
+	return shadow(ctx, repo, target, objectID, func(snapshots []Snapshot) *Snapshot {
+		for i := range snapshots {
+			if snapshots[i].Version == version {
+				return &snapshots[i]
+			}
+		}
+
+		return nil
+	})
+}
+
+//ShadowAt fills the target, which should be a pointer to the struct of the object, with its state as of the time
+func ShadowAt(ctx context.Context, repo Repository, target interface{}, objectID string, at time.Time) error {
+	return shadow(ctx, repo, target, objectID, func(snapshots []Snapshot) *Snapshot {
+		var found *Snapshot
+		for i := range snapshots {
+			if snapshots[i].Timestamp.After(at) {
+				break
+			}
+			found = &snapshots[i]
+		}
+
+		return found
+	})
+}
+
+func shadow(ctx context.Context, repo Repository, target interface{}, objectID string, find func([]Snapshot) *Snapshot) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("target should be a non-nil pointer")
+	}
+
+	elem := v.Elem()
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("target should be a pointer to a struct")
+	}
+
+	snapshots, err := repo.Snapshots(ctx, elem.Type().String(), objectID)
+	if err != nil {
+		return err
+	}
+
+	snapshot := find(snapshots)
+	if snapshot == nil {
+		return ErrSnapshotNotFound
+	}
+
+	state := reflect.New(elem.Type())
+	if err := json.Unmarshal(snapshot.State, state.Interface()); err != nil {
+		return fmt.Errorf("error on decode snapshot Error : %s", err.Error())
+	}
+	elem.Set(state.Elem())
+
+	return nil
+}
@@ -0,0 +1,726 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//SQLSchema documents the tables of SQLRepository after all of the migrations are applied, so it could be used
+//to create the tables without Migrate.
+//
+//	libra_commits     one row per commit, the version is unique per object
+//	libra_snapshots   the JSON encoded state of the object on each commit
+//	libra_changes     the diffs of each commit, the values are encoded as codec.TypedValue
+//
+//The old_value and new_value columns are NULL when the diff value is nil.
+//The properties column of libra_commits keeps the commit properties as a JSON object, it is NULL when there is none.
+const SQLSchema = `CREATE TABLE libra_commits (
+	sequence BIGINT NOT NULL PRIMARY KEY,
+	object_type VARCHAR(255) NOT NULL,
+	object_id VARCHAR(255) NOT NULL,
+	version BIGINT NOT NULL,
+	committed_at TIMESTAMP NOT NULL,
+	author VARCHAR(255) NOT NULL DEFAULT '',
+	properties TEXT,
+	UNIQUE (object_type, object_id, version)
+);
+` + sqlSnapshotsAndChangesSchema
+
+//sqlInitialSchema is the schema of the first migration, the later migrations in sqlMigrations alter it
+const sqlInitialSchema = `CREATE TABLE libra_commits (
+	sequence BIGINT NOT NULL PRIMARY KEY,
+	object_type VARCHAR(255) NOT NULL,
+	object_id VARCHAR(255) NOT NULL,
+	version BIGINT NOT NULL,
+	committed_at TIMESTAMP NOT NULL,
+	UNIQUE (object_type, object_id, version)
+);
+` + sqlSnapshotsAndChangesSchema
+
+const sqlSnapshotsAndChangesSchema = `CREATE TABLE libra_snapshots (
+	commit_sequence BIGINT NOT NULL PRIMARY KEY REFERENCES libra_commits (sequence),
+	object_type VARCHAR(255) NOT NULL,
+	object_id VARCHAR(255) NOT NULL,
+	version BIGINT NOT NULL,
+	state TEXT NOT NULL
+);
+CREATE TABLE libra_changes (
+	commit_sequence BIGINT NOT NULL REFERENCES libra_commits (sequence),
+	position INTEGER NOT NULL,
+	change_type VARCHAR(16) NOT NULL,
+	object_type VARCHAR(255) NOT NULL,
+	object_id VARCHAR(255) NOT NULL,
+	field TEXT NOT NULL,
+	old_value TEXT,
+	new_value TEXT,
+	PRIMARY KEY (commit_sequence, position)
+);`
+
+//sqlMigrations are applied in order, the index plus one is the schema version
+var sqlMigrations = [][]string{
+	strings.Split(sqlInitialSchema, ";\n"),
+	{
+		`ALTER TABLE libra_commits ADD COLUMN author VARCHAR(255) NOT NULL DEFAULT ''`,
+	},
+	{
+		`ALTER TABLE libra_commits ADD COLUMN properties TEXT`,
+	},
+}
+
+//PlaceholderStyle is the bind parameter style of the SQL driver
+type PlaceholderStyle int
+
+const (
+	//QuestionPlaceholder uses `?`, e.g. MySQL and SQLite
+	QuestionPlaceholder PlaceholderStyle = iota
+
+	//DollarPlaceholder uses `$1`, e.g. PostgreSQL
+	DollarPlaceholder
+)
+
+//SQLOptions configures the SQLRepository
+type SQLOptions struct {
+	//Placeholder is the bind parameter style of the driver
+	Placeholder PlaceholderStyle
+
+	//Migrate applies the pending migrations when the repository is opened
+	Migrate bool
+
+	//Registry resolves the value types of the stored diffs, see FileOptions.Registry
+	Registry *codec.Registry
+}
+
+//sqlFindChangesBatch is the number of the rows which are read at once when the path is matched in memory
+const sqlFindChangesBatch = 500
+
+const (
+	sqlSelectLastSequence = `SELECT COALESCE(MAX(sequence), 0) FROM libra_commits`
+	sqlSelectLastSnapshot = `SELECT commit_sequence, version, state FROM libra_snapshots WHERE object_type = ? AND object_id = ? ORDER BY version DESC LIMIT 1`
+	sqlInsertCommit       = `INSERT INTO libra_commits (sequence, object_type, object_id, version, committed_at, author, properties) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	sqlInsertSnapshot     = `INSERT INTO libra_snapshots (commit_sequence, object_type, object_id, version, state) VALUES (?, ?, ?, ?, ?)`
+	sqlInsertChange       = `INSERT INTO libra_changes (commit_sequence, position, change_type, object_type, object_id, field, old_value, new_value) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	sqlSelectCommits      = `SELECT sequence, version, committed_at, author, properties FROM libra_commits WHERE object_type = ? AND object_id = ? ORDER BY version`
+	sqlSelectSnapshots    = `SELECT s.commit_sequence, s.version, c.committed_at, s.state FROM libra_snapshots s JOIN libra_commits c ON c.sequence = s.commit_sequence WHERE s.object_type = ? AND s.object_id = ? ORDER BY s.version`
+	sqlSelectChanges      = `SELECT commit_sequence, change_type, object_type, object_id, field, old_value, new_value FROM libra_changes WHERE commit_sequence IN (SELECT sequence FROM libra_commits WHERE object_type = ? AND object_id = ?) ORDER BY commit_sequence, position`
+	sqlFindChanges        = `SELECT c.sequence, c.version, c.committed_at, c.author, c.properties, ch.change_type, ch.object_type, ch.object_id, ch.field, ch.old_value, ch.new_value ` +
+		`FROM libra_changes ch JOIN libra_commits c ON c.sequence = ch.commit_sequence ` +
+		`WHERE (? = '' OR c.object_type = ?) AND (? = '' OR c.object_id = ?) AND (? = '' OR c.author = ?) AND c.committed_at >= ? AND c.committed_at < ? ` +
+		`AND ch.change_type IN (?, ?, ?) ` +
+		`AND (? = '' OR ch.field LIKE ? ESCAPE '!') `
+	sqlSelectFindChanges       = sqlFindChanges + `ORDER BY c.sequence, ch.position LIMIT ? OFFSET ?`
+	sqlSelectFindChangesNewest = sqlFindChanges + `ORDER BY c.sequence DESC, ch.position LIMIT ? OFFSET ?`
+	sqlSelectObjects           = `SELECT DISTINCT object_type, object_id FROM libra_commits ORDER BY object_type, object_id`
+	sqlDeleteChanges           = `DELETE FROM libra_changes WHERE commit_sequence = ?`
+	sqlDeleteSnapshot          = `DELETE FROM libra_snapshots WHERE commit_sequence = ?`
+	sqlDeleteCommit            = `DELETE FROM libra_commits WHERE sequence = ?`
+
+	sqlCreateMigrations = `CREATE TABLE IF NOT EXISTS libra_schema_migrations (version INTEGER NOT NULL PRIMARY KEY)`
+	sqlSelectMigration  = `SELECT COALESCE(MAX(version), 0) FROM libra_schema_migrations`
+	sqlInsertMigration  = `INSERT INTO libra_schema_migrations (version) VALUES (?)`
+)
+
+//SQLRepository is a Repository on top of database/sql, so it works with any SQL driver.
+//The commits could join the transaction of the caller through CommitTx
+type SQLRepository struct {
+	db         *sql.DB
+	options    SQLOptions
+	statements map[string]*sql.Stmt
+}
+
+var (
+	_ Repository = (*SQLRepository)(nil)
+	_ Compactor  = (*SQLRepository)(nil)
+)
+
+//OpenSQLRepository applies the migrations when they are enabled and prepares the statements of the repository
+func OpenSQLRepository(ctx context.Context, db *sql.DB, options SQLOptions) (*SQLRepository, error) {
+	if options.Registry == nil {
+		options.Registry = codec.NewRegistry()
+	}
+
+	r := &SQLRepository{
+		db:         db,
+		options:    options,
+		statements: map[string]*sql.Stmt{},
+	}
+
+	if options.Migrate {
+		if err := r.Migrate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	queries := []string{
+		sqlSelectLastSequence, sqlSelectLastSnapshot, sqlInsertCommit, sqlInsertSnapshot, sqlInsertChange,
+		sqlSelectCommits, sqlSelectSnapshots, sqlSelectChanges, sqlSelectFindChanges, sqlSelectFindChangesNewest,
+		sqlSelectObjects, sqlDeleteChanges, sqlDeleteSnapshot, sqlDeleteCommit,
+	}
+	for _, query := range queries {
+		stmt, err := db.PrepareContext(ctx, r.rebind(query))
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("error on prepare statement %s Error : %s", query, err.Error())
+		}
+		r.statements[query] = stmt
+	}
+
+	return r, nil
+}
+
+//Migrate applies the pending migrations, each of them inside of its own transaction
+func (r *SQLRepository) Migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, sqlCreateMigrations); err != nil {
+		return err
+	}
+
+	current := 0
+	if err := r.db.QueryRowContext(ctx, sqlSelectMigration).Scan(&current); err != nil {
+		return err
+	}
+
+	for version := current + 1; version <= len(sqlMigrations); version++ {
+		if err := r.withTx(ctx, func(tx *sql.Tx) error {
+			for _, statement := range sqlMigrations[version-1] {
+				statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+				if statement == "" {
+					continue
+				}
+				if _, err := tx.ExecContext(ctx, statement); err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.ExecContext(ctx, r.rebind(sqlInsertMigration), version)
+			return err
+		}); err != nil {
+			return fmt.Errorf("error on migrate version %d Error : %s", version, err.Error())
+		}
+	}
+
+	return nil
+}
+
+//Register registers the types of the committed objects, see FileRepository.Register
+func (r *SQLRepository) Register(values ...interface{}) {
+	r.options.Registry.Register(values...)
+}
+
+//Close closes the prepared statements, the database is left open
+func (r *SQLRepository) Close() error {
+	var result error
+	for _, stmt := range r.statements {
+		if err := stmt.Close(); err != nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+func (r *SQLRepository) Commit(ctx context.Context, obj interface{}) (*Commit, error) {
+	var commit *Commit
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		commit, err = r.CommitTx(ctx, tx, obj)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return commit, nil
+}
+
+//CommitTx works like Commit inside of the transaction of the caller,
+//so the commit is written atomically along with the other changes of the transaction.
+//The concurrent transactions could collide on the commit sequence, the loser fails on its unique constraint and could be retried
+func (r *SQLRepository) CommitTx(ctx context.Context, tx *sql.Tx, obj interface{}) (*Commit, error) {
+	v, key, err := identify(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *Snapshot
+	last := Snapshot{}
+	var state string
+	err = r.stmt(ctx, tx, sqlSelectLastSnapshot).QueryRowContext(ctx, key.ObjectType, key.ObjectID).Scan(&last.Sequence, &last.Version, &state)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, err
+	default:
+		last.State = json.RawMessage(state)
+		previous = &last
+	}
+
+	change, err := compareSnapshot(ctx, v, key, previous)
+	if err != nil || change == nil {
+		return nil, err
+	}
+
+	var sequence int64
+	if err := r.stmt(ctx, tx, sqlSelectLastSequence).QueryRowContext(ctx).Scan(&sequence); err != nil {
+		return nil, err
+	}
+
+	version := int64(1)
+	if previous != nil {
+		version = previous.Version + 1
+	}
+
+	commit := newCommit(ctx, change, sequence+1, version)
+
+	properties, err := encodeProperties(commit.Properties)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.stmt(ctx, tx, sqlInsertCommit).ExecContext(ctx,
+		commit.Sequence, commit.ObjectType, commit.ObjectID, commit.Version, commit.Timestamp, commit.Author, properties); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.stmt(ctx, tx, sqlInsertSnapshot).ExecContext(ctx,
+		commit.Sequence, commit.ObjectType, commit.ObjectID, commit.Version, string(change.State)); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertChanges(ctx, tx, commit); err != nil {
+		return nil, err
+	}
+
+	return &commit, nil
+}
+
+func (r *SQLRepository) insertChanges(ctx context.Context, tx *sql.Tx, commit Commit) error {
+	for i, d := range commit.Diffs {
+		oldValue, err := r.encodeValue(d.Old)
+		if err != nil {
+			return err
+		}
+
+		newValue, err := r.encodeValue(d.New)
+		if err != nil {
+			return err
+		}
+
+		if _, err := r.stmt(ctx, tx, sqlInsertChange).ExecContext(ctx,
+			commit.Sequence, i, string(d.ChangeType), d.ObjectType, d.ObjectID, d.Field, oldValue, newValue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLRepository) Commits(ctx context.Context, objectType, objectID string) ([]Commit, error) {
+	rows, err := r.statements[sqlSelectCommits].QueryContext(ctx, objectType, objectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commits := []Commit{}
+	index := map[int64]int{}
+	for rows.Next() {
+		commit := Commit{ObjectType: objectType, ObjectID: objectID, Diffs: []diff.Diff{}}
+		var properties sql.NullString
+		if err := rows.Scan(&commit.Sequence, &commit.Version, &commit.Timestamp, &commit.Author, &properties); err != nil {
+			return nil, err
+		}
+		if commit.Properties, err = decodeProperties(commit.Sequence, properties); err != nil {
+			return nil, err
+		}
+		index[commit.Sequence] = len(commits)
+		commits = append(commits, commit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	changes, err := r.statements[sqlSelectChanges].QueryContext(ctx, objectType, objectID)
+	if err != nil {
+		return nil, err
+	}
+	defer changes.Close()
+
+	for changes.Next() {
+		sequence, d, err := r.scanChange(changes)
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := index[sequence]; ok {
+			commits[i].Diffs = append(commits[i].Diffs, d)
+		}
+	}
+
+	return commits, changes.Err()
+}
+
+func (r *SQLRepository) Snapshots(ctx context.Context, objectType, objectID string) ([]Snapshot, error) {
+	rows, err := r.statements[sqlSelectSnapshots].QueryContext(ctx, objectType, objectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []Snapshot{}
+	for rows.Next() {
+		snapshot := Snapshot{ObjectType: objectType, ObjectID: objectID}
+		var state string
+		if err := rows.Scan(&snapshot.Sequence, &snapshot.Version, &snapshot.Timestamp, &state); err != nil {
+			return nil, err
+		}
+		snapshot.State = json.RawMessage(state)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+//FindChanges filters the changes, orders them and applies the offset and limit inside of the database.
+//The path is also matched in memory, because LIKE could be case-insensitive and cannot express the character classes,
+//so the rows of a query with a path are read in batches until the page is filled
+func (r *SQLRepository) FindChanges(ctx context.Context, q Query) ([]Change, error) {
+	from, to := q.From, q.To
+	if from.IsZero() {
+		from = time.Unix(0, 0).UTC()
+	}
+	if to.IsZero() {
+		to = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	}
+
+	changeTypes := []diff.ChangeType{diff.New, diff.Changed, diff.Removed}
+	for i := range changeTypes {
+		if len(q.ChangeTypes) > 0 {
+			changeTypes[i] = q.ChangeTypes[i%len(q.ChangeTypes)]
+		}
+	}
+
+	query := sqlSelectFindChangesNewest
+	if q.OldestFirst {
+		query = sqlSelectFindChanges
+	}
+
+	like := likePattern(q.Path)
+	offset, limit := int64(q.Offset), int64(q.Limit)
+	if q.Path != "" {
+		offset, limit = 0, sqlFindChangesBatch
+	} else if limit <= 0 {
+		limit = math.MaxInt64
+	}
+
+	changes := []Change{}
+	skipped := 0
+	for {
+		read, err := r.findChanges(ctx, query, func(change Change) bool {
+			if q.Path != "" {
+				if !q.matchDiff(change.Diff) {
+					return true
+				}
+				if skipped < q.Offset {
+					skipped++
+					return true
+				}
+			}
+
+			changes = append(changes, change)
+			return q.Limit <= 0 || len(changes) < q.Limit
+		}, q.ObjectType, q.ObjectType, q.ObjectID, q.ObjectID, q.Author, q.Author, from, to,
+			string(changeTypes[0]), string(changeTypes[1]), string(changeTypes[2]), q.Path, like, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if q.Path == "" || read < limit || (q.Limit > 0 && len(changes) >= q.Limit) {
+			return changes, nil
+		}
+		offset += limit
+	}
+}
+
+//findChanges reads the rows of the query until fn returns false, it returns the number of the rows which are read
+func (r *SQLRepository) findChanges(ctx context.Context, query string, fn func(change Change) bool, args ...interface{}) (int64, error) {
+	rows, err := r.statements[query].QueryContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var read int64
+	for rows.Next() {
+		read++
+		change := Change{}
+		var changeType string
+		var properties, oldValue, newValue sql.NullString
+		if err := rows.Scan(&change.Sequence, &change.Version, &change.Timestamp, &change.Author, &properties,
+			&changeType, &change.ObjectType, &change.ObjectID, &change.Field, &oldValue, &newValue); err != nil {
+			return 0, err
+		}
+		change.ChangeType = diff.ChangeType(changeType)
+
+		if change.Diff, err = r.decodeDiff(change.Sequence, change.Diff, oldValue, newValue); err != nil {
+			return 0, err
+		}
+		if change.Properties, err = decodeProperties(change.Sequence, properties); err != nil {
+			return 0, err
+		}
+		if !fn(change) {
+			return read, nil
+		}
+	}
+
+	return read, rows.Err()
+}
+
+//Compact reads the history of the objects, then removes the commits and replaces the squashed diffs inside of a transaction.
+//The commits which are written during the compaction are retained
+func (r *SQLRepository) Compact(ctx context.Context, policy RetentionPolicy) (int, error) {
+	rows, err := r.statements[sqlSelectObjects].QueryContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	keys := []objectKey{}
+	for rows.Next() {
+		key := objectKey{}
+		if err := rows.Scan(&key.ObjectType, &key.ObjectID); err != nil {
+			return 0, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	retained := map[int64]Commit{}
+	replaced := map[int64]bool{}
+	removed := []int64{}
+	for _, key := range keys {
+		commits, err := r.Commits(ctx, key.ObjectType, key.ObjectID)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := retain(ctx, policy, now, commits, retained, replaced); err != nil {
+			return 0, err
+		}
+
+		for _, commit := range commits {
+			if _, ok := retained[commit.Sequence]; !ok {
+				removed = append(removed, commit.Sequence)
+			}
+		}
+	}
+
+	if len(removed) == 0 && len(replaced) == 0 {
+		return 0, nil
+	}
+
+	err = r.withTx(ctx, func(tx *sql.Tx) error {
+		for sequence := range replaced {
+			if _, err := r.stmt(ctx, tx, sqlDeleteChanges).ExecContext(ctx, sequence); err != nil {
+				return err
+			}
+			if err := r.insertChanges(ctx, tx, retained[sequence]); err != nil {
+				return err
+			}
+		}
+
+		for _, sequence := range removed {
+			for _, query := range []string{sqlDeleteChanges, sqlDeleteSnapshot, sqlDeleteCommit} {
+				if _, err := r.stmt(ctx, tx, query).ExecContext(ctx, sequence); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error on compact Error : %s", err.Error())
+	}
+
+	return len(removed), nil
+}
+
+func (r *SQLRepository) scanChange(rows *sql.Rows) (int64, diff.Diff, error) {
+	var sequence int64
+	var changeType string
+	var oldValue, newValue sql.NullString
+	d := diff.Diff{}
+	if err := rows.Scan(&sequence, &changeType, &d.ObjectType, &d.ObjectID, &d.Field, &oldValue, &newValue); err != nil {
+		return 0, diff.Diff{}, err
+	}
+	d.ChangeType = diff.ChangeType(changeType)
+
+	d, err := r.decodeDiff(sequence, d, oldValue, newValue)
+	return sequence, d, err
+}
+
+func (r *SQLRepository) decodeDiff(sequence int64, d diff.Diff, oldValue, newValue sql.NullString) (diff.Diff, error) {
+	var err error
+	if d.Old, err = r.decodeValue(oldValue); err != nil {
+		return diff.Diff{}, fmt.Errorf("error on decode commit %d Error : %s", sequence, err.Error())
+	}
+	if d.New, err = r.decodeValue(newValue); err != nil {
+		return diff.Diff{}, fmt.Errorf("error on decode commit %d Error : %s", sequence, err.Error())
+	}
+
+	return d, nil
+}
+
+func (r *SQLRepository) encodeValue(v interface{}) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	r.options.Registry.Register(v)
+
+	tv, err := codec.EncodeValue(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	b, err := json.Marshal(tv)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func (r *SQLRepository) decodeValue(s sql.NullString) (interface{}, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	tv := &codec.TypedValue{}
+	if err := json.Unmarshal([]byte(s.String), tv); err != nil {
+		return nil, err
+	}
+
+	return decodeValue(tv, r.options.Registry)
+}
+
+func encodeProperties(properties map[string]string) (sql.NullString, error) {
+	if len(properties) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	b, err := json.Marshal(properties)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func decodeProperties(sequence int64, s sql.NullString) (map[string]string, error) {
+	if !s.Valid || s.String == "" {
+		return nil, nil
+	}
+
+	properties := map[string]string{}
+	if err := json.Unmarshal([]byte(s.String), &properties); err != nil {
+		return nil, fmt.Errorf("error on decode properties of commit %d Error : %s", sequence, err.Error())
+	}
+
+	return properties, nil
+}
+
+//likePattern translates the path.Match pattern of Query.Path into a LIKE pattern which is escaped by `!`.
+//The wildcards and the character classes are translated into `%`, since they could match the escaped characters
+//of the field as well, so the pattern only narrows down the rows which are matched again in memory
+func likePattern(pattern string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*', '?':
+			b.WriteByte('%')
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return pattern
+			}
+			b.WriteByte('%')
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			if pattern[i] == '.' || pattern[i] == '\\' {
+				//The field keeps the escape of the dots and the backslashes
+				b.WriteByte('\\')
+			}
+			b.WriteString(escapeLike(pattern[i]))
+		default:
+			b.WriteString(escapeLike(c))
+		}
+	}
+
+	return b.String()
+}
+
+func escapeLike(c byte) string {
+	switch c {
+	case '%', '_', '!':
+		return "!" + string(c)
+	}
+
+	return string(c)
+}
+
+//stmt returns the prepared statement, bound to the transaction when it is not nil
+func (r *SQLRepository) stmt(ctx context.Context, tx *sql.Tx, query string) *sql.Stmt {
+	if tx == nil {
+		return r.statements[query]
+	}
+
+	return tx.StmtContext(ctx, r.statements[query])
+}
+
+func (r *SQLRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//rebind replaces the `?` placeholders according to the placeholder style
+func (r *SQLRepository) rebind(query string) string {
+	if r.options.Placeholder != DollarPlaceholder {
+		return query
+	}
+
+	b := strings.Builder{}
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,161 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/repository"
+)
+
+func openSQLRepository(t *testing.T, options repository.SQLOptions) (*sql.DB, *repository.SQLRepository) {
+	db, err := sql.Open("libra-fake", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	repo, err := repository.OpenSQLRepository(context.Background(), db, options)
+	if err != nil {
+		t.Fatalf("OpenSQLRepository() error = %v", err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	return db, repo
+}
+
+func TestSQLRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) repository.Repository {
+		_, repo := openSQLRepository(t, repository.SQLOptions{Migrate: true})
+		return repo
+	})
+}
+
+func TestSQLRepository_DollarPlaceholder(t *testing.T) {
+	testRepository(t, func(t *testing.T) repository.Repository {
+		_, repo := openSQLRepository(t, repository.SQLOptions{Migrate: true, Placeholder: repository.DollarPlaceholder})
+		return repo
+	})
+}
+
+func TestSQLRepository_CommitTx(t *testing.T) {
+	ctx := context.Background()
+	db, repo := openSQLRepository(t, repository.SQLOptions{Migrate: true})
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("DB.BeginTx() error = %v", err)
+	}
+	if _, err := repo.CommitTx(ctx, tx, person{ID: 1, Name: "test1"}); err != nil {
+		t.Fatalf("SQLRepository.CommitTx() error = %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Tx.Rollback() error = %v", err)
+	}
+
+	commits, err := repo.Commits(ctx, "repository_test.person", "1")
+	if err != nil || len(commits) != 0 {
+		t.Fatalf("SQLRepository.Commits() = %v, %v, want no commits after rollback", commits, err)
+	}
+
+	tx, err = db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("DB.BeginTx() error = %v", err)
+	}
+	if _, err := repo.CommitTx(ctx, tx, person{ID: 1, Name: "test2"}); err != nil {
+		t.Fatalf("SQLRepository.CommitTx() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Tx.Commit() error = %v", err)
+	}
+
+	commits, err = repo.Commits(ctx, "repository_test.person", "1")
+	if err != nil || len(commits) != 1 || commits[0].Version != 1 {
+		t.Fatalf("SQLRepository.Commits() = %v, %v, want the committed version", commits, err)
+	}
+}
+
+func TestSQLRepository_ReopenUnregistered(t *testing.T) {
+	ctx := context.Background()
+	db, repo := openSQLRepository(t, repository.SQLOptions{Migrate: true})
+	if _, err := repo.Commit(ctx, person{ID: 1, Name: "test1"}); err != nil {
+		t.Fatalf("SQLRepository.Commit() error = %v", err)
+	}
+
+	reopened, err := repository.OpenSQLRepository(ctx, db, repository.SQLOptions{})
+	if err != nil {
+		t.Fatalf("OpenSQLRepository() error = %v", err)
+	}
+	defer reopened.Close()
+
+	changes, err := reopened.FindChanges(ctx, repository.Query{})
+	if err != nil {
+		t.Fatalf("SQLRepository.FindChanges() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"ID":      float64(1),
+		"Name":    "test1",
+		"Age":     float64(0),
+		"Address": map[string]interface{}{"Street": "", "City": ""},
+	}
+	if len(changes) != 1 || !reflect.DeepEqual(changes[0].New, want) {
+		t.Errorf("SQLRepository.FindChanges() = %+v, want the generic value %v", changes, want)
+	}
+}
+
+func TestSQLRepository_FindChangesBatches(t *testing.T) {
+	ctx := context.Background()
+	_, repo := openSQLRepository(t, repository.SQLOptions{Migrate: true})
+	for i := 0; i < 1200; i++ {
+		if _, err := repo.Commit(ctx, person{ID: 1, Name: strconv.Itoa(i), Age: i % 2}); err != nil {
+			t.Fatalf("SQLRepository.Commit() error = %v", err)
+		}
+	}
+
+	//The pattern is translated into LIKE '_%' which matches Name too, so the rows are matched again in memory
+	changes, err := repo.FindChanges(ctx, repository.Query{Path: "[nA]*", Offset: 500, Limit: 2})
+	if err != nil {
+		t.Fatalf("SQLRepository.FindChanges() error = %v", err)
+	}
+
+	got := []int64{}
+	for _, c := range changes {
+		got = append(got, c.Sequence)
+	}
+	if want := []int64{700, 699}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SQLRepository.FindChanges() sequences = %v, want %v", got, want)
+	}
+}
+
+func TestSQLRepository_Migrate(t *testing.T) {
+	_, repo := openSQLRepository(t, repository.SQLOptions{Migrate: true})
+
+	if err := repo.Migrate(context.Background()); err != nil {
+		t.Errorf("SQLRepository.Migrate() error = %v", err)
+	}
+}
+
+func TestOpenSQLRepository_WithoutMigration(t *testing.T) {
+	db, err := sql.Open("libra-fake", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	repo, err := repository.OpenSQLRepository(context.Background(), db, repository.SQLOptions{})
+	if err != nil {
+		t.Fatalf("OpenSQLRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := repo.Commit(context.Background(), person{ID: 1}); err == nil {
+		t.Errorf("SQLRepository.Commit() error = nil, wantErr true")
+	}
+}
@@ -0,0 +1,421 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//fakeDriver is an in-process database/sql driver which understands the statements of SQLRepository
+type fakeDriver struct {
+	mu        sync.Mutex
+	databases map[string]*fakeDatabase
+}
+
+var fakeSQLDriver = &fakeDriver{databases: map[string]*fakeDatabase{}}
+
+func init() {
+	sql.Register("libra-fake", fakeSQLDriver)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	db, ok := d.databases[name]
+	if !ok {
+		db = &fakeDatabase{tables: fakeTables{}}
+		d.databases[name] = db
+	}
+
+	return &fakeConn{db: db}, nil
+}
+
+type fakeRow map[string]driver.Value
+
+type fakeTables map[string][]fakeRow
+
+func (t fakeTables) clone() fakeTables {
+	cloned := fakeTables{}
+	for name, rows := range t {
+		cloned[name] = append([]fakeRow{}, rows...)
+	}
+
+	return cloned
+}
+
+type fakeDatabase struct {
+	//txMu is held by a transaction from its begin until its end
+	txMu   sync.Mutex
+	mu     sync.Mutex
+	tables fakeTables
+}
+
+type fakeConn struct {
+	db     *fakeDatabase
+	backup fakeTables
+	inTx   bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: normalizeQuery(query)}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.db.txMu.Lock()
+	c.db.mu.Lock()
+	c.backup = c.db.tables.clone()
+	c.db.mu.Unlock()
+	c.inTx = true
+
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.inTx = false
+	c.backup = nil
+	c.db.txMu.Unlock()
+
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	c.db.tables = c.backup
+	c.db.mu.Unlock()
+
+	return c.Commit()
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, _, err := s.run(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	columns, rows, err := s.run(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+func (s *fakeStmt) run(args []driver.Value) ([]string, [][]driver.Value, error) {
+	if !s.conn.inTx {
+		s.conn.db.txMu.Lock()
+		defer s.conn.db.txMu.Unlock()
+	}
+
+	s.conn.db.mu.Lock()
+	defer s.conn.db.mu.Unlock()
+
+	for _, handler := range fakeHandlers {
+		if strings.HasPrefix(s.query, handler.prefix) {
+			return handler.run(s.query, s.conn.db.tables, args)
+		}
+	}
+
+	return nil, nil, fmt.Errorf("fake driver does not support %s", s.query)
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+var placeholderPattern = regexp.MustCompile(`\$\d+`)
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(placeholderPattern.ReplaceAllString(query, "?")), " ")
+}
+
+type fakeHandler struct {
+	prefix string
+	run    func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error)
+}
+
+func insertInto(table string, columns ...string) func(string, fakeTables, []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		row := fakeRow{}
+		for i, column := range columns {
+			row[column] = args[i]
+		}
+
+		if _, ok := tables[table]; !ok {
+			return nil, nil, fmt.Errorf("table %s does not exist", table)
+		}
+
+		//The first column is the primary key of the fake tables
+		for _, existing := range tables[table] {
+			if existing[columns[0]] == row[columns[0]] && (table != "libra_changes" || existing["position"] == row["position"]) {
+				return nil, nil, fmt.Errorf("duplicate key %v on %s", row[columns[0]], table)
+			}
+		}
+
+		tables[table] = append(tables[table], row)
+		return nil, nil, nil
+	}
+}
+
+func createTable(table string) func(string, fakeTables, []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		if _, ok := tables[table]; !ok {
+			tables[table] = []fakeRow{}
+		}
+		return nil, nil, nil
+	}
+}
+
+func maxOf(table, column string) func(string, fakeTables, []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		result := int64(0)
+		for _, row := range tables[table] {
+			if v := row[column].(int64); v > result {
+				result = v
+			}
+		}
+		return []string{"max"}, [][]driver.Value{{result}}, nil
+	}
+}
+
+func deleteFrom(table, column string) func(string, fakeTables, []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		rows := []fakeRow{}
+		for _, row := range tables[table] {
+			if row[column] != args[0] {
+				rows = append(rows, row)
+			}
+		}
+		tables[table] = rows
+		return nil, nil, nil
+	}
+}
+
+func filterObject(rows []fakeRow, args []driver.Value) []fakeRow {
+	result := []fakeRow{}
+	for _, row := range rows {
+		if row["object_type"] == args[0] && row["object_id"] == args[1] {
+			result = append(result, row)
+		}
+	}
+
+	return result
+}
+
+func commitTimes(tables fakeTables) map[int64]driver.Value {
+	result := map[int64]driver.Value{}
+	for _, row := range tables["libra_commits"] {
+		result[row["sequence"].(int64)] = row["committed_at"]
+	}
+
+	return result
+}
+
+var fakeHandlers = []fakeHandler{
+	{"DELETE FROM libra_changes", deleteFrom("libra_changes", "commit_sequence")},
+	{"DELETE FROM libra_snapshots", deleteFrom("libra_snapshots", "commit_sequence")},
+	{"DELETE FROM libra_commits", deleteFrom("libra_commits", "sequence")},
+	{"SELECT DISTINCT object_type, object_id FROM libra_commits", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		seen := map[[2]driver.Value]bool{}
+		result := [][]driver.Value{}
+		for _, row := range tables["libra_commits"] {
+			key := [2]driver.Value{row["object_type"], row["object_id"]}
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, []driver.Value{row["object_type"], row["object_id"]})
+			}
+		}
+		return []string{"object_type", "object_id"}, result, nil
+	}},
+	{"CREATE TABLE IF NOT EXISTS libra_schema_migrations", createTable("libra_schema_migrations")},
+	{"CREATE TABLE libra_commits", createTable("libra_commits")},
+	{"CREATE TABLE libra_snapshots", createTable("libra_snapshots")},
+	{"CREATE TABLE libra_changes", createTable("libra_changes")},
+	{"SELECT COALESCE(MAX(version), 0) FROM libra_schema_migrations", maxOf("libra_schema_migrations", "version")},
+	{"INSERT INTO libra_schema_migrations", insertInto("libra_schema_migrations", "version")},
+	{"SELECT COALESCE(MAX(sequence), 0) FROM libra_commits", maxOf("libra_commits", "sequence")},
+	{"ALTER TABLE libra_commits ADD COLUMN author", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		for _, row := range tables["libra_commits"] {
+			row["author"] = ""
+		}
+		return nil, nil, nil
+	}},
+	{"ALTER TABLE libra_commits ADD COLUMN properties", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		for _, row := range tables["libra_commits"] {
+			row["properties"] = nil
+		}
+		return nil, nil, nil
+	}},
+	{"INSERT INTO libra_commits", insertInto("libra_commits", "sequence", "object_type", "object_id", "version", "committed_at", "author", "properties")},
+	{"INSERT INTO libra_snapshots", insertInto("libra_snapshots", "commit_sequence", "object_type", "object_id", "version", "state")},
+	{"INSERT INTO libra_changes", insertInto("libra_changes", "commit_sequence", "position", "change_type", "object_type", "object_id", "field", "old_value", "new_value")},
+	{"SELECT commit_sequence, version, state FROM libra_snapshots", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		rows := filterObject(tables["libra_snapshots"], args)
+		if len(rows) == 0 {
+			return []string{"commit_sequence", "version", "state"}, nil, nil
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i]["version"].(int64) > rows[j]["version"].(int64) })
+		return []string{"commit_sequence", "version", "state"}, [][]driver.Value{{rows[0]["commit_sequence"], rows[0]["version"], rows[0]["state"]}}, nil
+	}},
+	{"SELECT sequence, version, committed_at, author, properties FROM libra_commits", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		rows := filterObject(tables["libra_commits"], args)
+		sort.Slice(rows, func(i, j int) bool { return rows[i]["version"].(int64) < rows[j]["version"].(int64) })
+		result := [][]driver.Value{}
+		for _, row := range rows {
+			result = append(result, []driver.Value{row["sequence"], row["version"], row["committed_at"], row["author"], row["properties"]})
+		}
+		return []string{"sequence", "version", "committed_at", "author", "properties"}, result, nil
+	}},
+	{"SELECT c.sequence, c.version, c.committed_at, c.author, c.properties, ch.change_type", findChanges},
+	{"SELECT s.commit_sequence, s.version, c.committed_at, s.state FROM libra_snapshots", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		rows := filterObject(tables["libra_snapshots"], args)
+		sort.Slice(rows, func(i, j int) bool { return rows[i]["version"].(int64) < rows[j]["version"].(int64) })
+		times := commitTimes(tables)
+		result := [][]driver.Value{}
+		for _, row := range rows {
+			result = append(result, []driver.Value{row["commit_sequence"], row["version"], times[row["commit_sequence"].(int64)], row["state"]})
+		}
+		return []string{"commit_sequence", "version", "committed_at", "state"}, result, nil
+	}},
+	{"SELECT commit_sequence, change_type, object_type, object_id, field, old_value, new_value FROM libra_changes WHERE commit_sequence IN", func(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+		sequences := map[int64]bool{}
+		for _, row := range filterObject(tables["libra_commits"], args) {
+			sequences[row["sequence"].(int64)] = true
+		}
+		rows := []fakeRow{}
+		for _, row := range tables["libra_changes"] {
+			if sequences[row["commit_sequence"].(int64)] {
+				rows = append(rows, row)
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			if rows[i]["commit_sequence"] != rows[j]["commit_sequence"] {
+				return rows[i]["commit_sequence"].(int64) < rows[j]["commit_sequence"].(int64)
+			}
+			return rows[i]["position"].(int64) < rows[j]["position"].(int64)
+		})
+		result := [][]driver.Value{}
+		for _, row := range rows {
+			result = append(result, []driver.Value{row["commit_sequence"], row["change_type"], row["object_type"], row["object_id"], row["field"], row["old_value"], row["new_value"]})
+		}
+		return []string{"commit_sequence", "change_type", "object_type", "object_id", "field", "old_value", "new_value"}, result, nil
+	}},
+}
+
+//findChanges runs the queries of SQLRepository.FindChanges, LIKE is case-insensitive the same way as SQLite
+func findChanges(query string, tables fakeTables, args []driver.Value) ([]string, [][]driver.Value, error) {
+	commits := map[int64]fakeRow{}
+	for _, row := range tables["libra_commits"] {
+		at := row["committed_at"].(time.Time)
+		if (args[0] == "" || row["object_type"] == args[1]) &&
+			(args[2] == "" || row["object_id"] == args[3]) &&
+			(args[4] == "" || row["author"] == args[5]) &&
+			!at.Before(args[6].(time.Time)) && at.Before(args[7].(time.Time)) {
+			commits[row["sequence"].(int64)] = row
+		}
+	}
+	like := fakeLike(args[12].(string))
+	rows := []fakeRow{}
+	for _, row := range tables["libra_changes"] {
+		field := row["field"].(string)
+		if _, ok := commits[row["commit_sequence"].(int64)]; ok &&
+			(row["change_type"] == args[8] || row["change_type"] == args[9] || row["change_type"] == args[10]) &&
+			(args[11] == "" || like.MatchString(field)) {
+			rows = append(rows, row)
+		}
+	}
+	newestFirst := strings.Contains(query, "ORDER BY c.sequence DESC")
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i]["commit_sequence"] != rows[j]["commit_sequence"] {
+			return (rows[i]["commit_sequence"].(int64) < rows[j]["commit_sequence"].(int64)) != newestFirst
+		}
+		return rows[i]["position"].(int64) < rows[j]["position"].(int64)
+	})
+	if offset := int(args[14].(int64)); offset < len(rows) {
+		rows = rows[offset:]
+	} else {
+		rows = nil
+	}
+	if limit := args[13].(int64); limit < int64(len(rows)) {
+		rows = rows[:limit]
+	}
+	result := [][]driver.Value{}
+	for _, row := range rows {
+		c := commits[row["commit_sequence"].(int64)]
+		result = append(result, []driver.Value{c["sequence"], c["version"], c["committed_at"], c["author"], c["properties"],
+			row["change_type"], row["object_type"], row["object_id"], row["field"], row["old_value"], row["new_value"]})
+	}
+	return []string{"sequence", "version", "committed_at", "author", "properties", "change_type", "object_type", "object_id", "field", "old_value", "new_value"}, result, nil
+}
+
+//fakeLike translates the LIKE pattern which is escaped by `!` into a regular expression
+func fakeLike(pattern string) *regexp.Regexp {
+	b := strings.Builder{}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '!' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		case c == '%':
+			b.WriteString(".*")
+		case c == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return regexp.MustCompile("(?is)^" + b.String() + "$")
+}
@@ -0,0 +1,121 @@
+//go:build sqlite
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra"
+	"github.com/haritsfahreza/libra/pkg/repository"
+	_ "modernc.org/sqlite"
+)
+
+//The tests against SQLite are run by go test -tags sqlite ./pkg/repository
+
+//openSQLiteDB opens a database file which transactions take the write lock on begin, otherwise the concurrent commits
+//could not upgrade their read locks and fail with SQLITE_BUSY
+func openSQLiteDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "libra.db")+"?_txlock=immediate&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+func TestSQLRepository_SQLite(t *testing.T) {
+	placeholders := map[string]repository.PlaceholderStyle{
+		"question placeholder": repository.QuestionPlaceholder,
+		"dollar placeholder":   repository.DollarPlaceholder,
+	}
+	for name, placeholder := range placeholders {
+		placeholder := placeholder
+		t.Run(name, func(t *testing.T) {
+			testRepository(t, func(t *testing.T) repository.Repository {
+				repo, err := repository.OpenSQLRepository(context.Background(), openSQLiteDB(t), repository.SQLOptions{Migrate: true, Placeholder: placeholder})
+				if err != nil {
+					t.Fatalf("OpenSQLRepository() error = %v", err)
+				}
+				t.Cleanup(func() {
+					repo.Close()
+				})
+
+				return repo
+			})
+		})
+	}
+}
+
+func TestSQLSchema_SQLite(t *testing.T) {
+	ctx := context.Background()
+	migrated := openSQLiteDB(t)
+	repo, err := repository.OpenSQLRepository(ctx, migrated, repository.SQLOptions{Migrate: true})
+	if err != nil {
+		t.Fatalf("OpenSQLRepository() error = %v", err)
+	}
+	repo.Close()
+
+	created := openSQLiteDB(t)
+	for _, statement := range strings.Split(repository.SQLSchema, ";\n") {
+		if _, err := created.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("DB.ExecContext() error = %v", err)
+		}
+	}
+
+	for _, table := range []string{"libra_commits", "libra_snapshots", "libra_changes"} {
+		want := sqliteColumns(t, migrated, table)
+		if got := sqliteColumns(t, created, table); !reflect.DeepEqual(got, want) {
+			t.Errorf("SQLSchema columns of %s = %v, want the migrated columns %v", table, got, want)
+		}
+	}
+
+	repo, err = repository.OpenSQLRepository(ctx, created, repository.SQLOptions{})
+	if err != nil {
+		t.Fatalf("OpenSQLRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	if _, err := repo.Commit(libra.WithAuthor(ctx, "ann"), person{ID: 1, Name: "test1"}); err != nil {
+		t.Fatalf("SQLRepository.Commit() error = %v", err)
+	}
+	changes, err := repo.FindChanges(ctx, repository.Query{Author: "ann"})
+	if err != nil || len(changes) != 1 {
+		t.Errorf("SQLRepository.FindChanges() = %v, %v, want the committed change", changes, err)
+	}
+}
+
+//sqliteColumns gives the name, the type, the nullability and the default value of each column of the table
+func sqliteColumns(t *testing.T, db *sql.DB, table string) []string {
+	rows, err := db.Query("SELECT name, type, \"notnull\", COALESCE(dflt_value, '') FROM pragma_table_info(?) ORDER BY name", table)
+	if err != nil {
+		t.Fatalf("DB.Query() error = %v", err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for rows.Next() {
+		var name, typ, dflt string
+		var notNull bool
+		if err := rows.Scan(&name, &typ, &notNull, &dflt); err != nil {
+			t.Fatalf("Rows.Scan() error = %v", err)
+		}
+		column := name + " " + typ
+		if notNull {
+			column += " NOT NULL"
+		}
+		if dflt != "" {
+			column += " DEFAULT " + dflt
+		}
+		columns = append(columns, column)
+	}
+
+	return columns
+}
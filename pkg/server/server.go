@@ -0,0 +1,368 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/codec"
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/jsondoc"
+	"github.com/haritsfahreza/libra/pkg/patcher"
+	"github.com/haritsfahreza/libra/pkg/render"
+)
+
+//DefaultMaxBodySize is the maximum size of a request body when Options.MaxBodySize is zero
+const DefaultMaxBodySize = 1 << 20
+
+const (
+	mediaJSON      = "application/json"
+	mediaJSONPatch = "application/json-patch+json"
+	mediaText      = "text/plain"
+	mediaMarkdown  = "text/markdown"
+	mediaHTML      = "text/html"
+)
+
+//diffMediaTypes are the media types of the diffs, the first one is the default
+var diffMediaTypes = []string{mediaJSON, mediaJSONPatch, mediaText, mediaMarkdown, mediaHTML}
+
+//Options is the options of the Server
+type Options struct {
+	//MaxBodySize is the maximum size in bytes of a request body, the larger requests are rejected with 413.
+	//Zero uses DefaultMaxBodySize
+	MaxBodySize int64
+}
+
+//Server serves the comparison of the JSON documents over HTTP with the following endpoints:
+//
+//	POST /diff   compares {"old": ..., "new": ...} and writes the diffs in the media type of the Accept header
+//	POST /patch  applies {"document": ..., "diffs": [...]} or {"document": ..., "patch": [...]} and writes the document
+//	GET /healthz reports the health of the server
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+var _ http.Handler = (*Server)(nil)
+
+//DiffRequest is the body of POST /diff, the name is given as the object type of the diffs
+type DiffRequest struct {
+	Name string          `json:"name,omitempty"`
+	Old  json.RawMessage `json:"old"`
+	New  json.RawMessage `json:"new"`
+}
+
+//PatchRequest is the body of POST /patch, which has either the JSON diffs or the JSON Patch operations
+type PatchRequest struct {
+	Document json.RawMessage            `json:"document"`
+	Diffs    []codec.JSONDiff           `json:"diffs,omitempty"`
+	Patch    []codec.JSONPatchOperation `json:"patch,omitempty"`
+}
+
+//ErrorResponse is the body of the failed responses, the conflicts are given when the patch is conflicted
+type ErrorResponse struct {
+	Error     string             `json:"error"`
+	Conflicts []ConflictResponse `json:"conflicts,omitempty"`
+}
+
+//ConflictResponse is a diff which is conflicted with the document
+type ConflictResponse struct {
+	Field    string      `json:"field"`
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual"`
+}
+
+//New returns a new Server
+func New(opts Options) *Server {
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/diff", s.handleDiff)
+	s.mux.HandleFunc("/patch", s.handlePatch)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) maxBodySize() int64 {
+	if s.opts.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+
+	return s.opts.MaxBodySize
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", r.Method))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := s.accept(w, r, diffMediaTypes)
+	if !ok {
+		return
+	}
+
+	req := DiffRequest{}
+	if !s.decode(w, r, &req) {
+		return
+	}
+
+	if req.Old == nil || req.New == nil {
+		writeError(w, http.StatusBadRequest, "old and new documents are required")
+		return
+	}
+
+	old, err := jsondoc.Decode(bytes.NewReader(req.Old))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	new, err := jsondoc.Decode(bytes.NewReader(req.New))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	diffs, err := jsondoc.Compare(r.Context(), old, new)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "document"
+	}
+	for i := range diffs {
+		diffs[i].ObjectType = name
+	}
+
+	switch mediaType {
+	case mediaJSON:
+		encoded, err := codec.EncodeJSONDiffs(diffs)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, encoded)
+	case mediaJSONPatch:
+		writeEncoded(w, http.StatusOK, mediaJSONPatch, codec.EncodeJSONPatch(diffs, r.URL.Query().Get("test") == "true"))
+	default:
+		writeRendered(w, r, mediaType, diffs)
+	}
+}
+
+func writeRendered(w http.ResponseWriter, r *http.Request, mediaType string, diffs []diff.Diff) {
+	var renderer render.Renderer
+	switch mediaType {
+	case mediaMarkdown:
+		renderer = &render.MarkdownRenderer{}
+	case mediaHTML:
+		renderer = &render.HTMLRenderer{Title: "libra diff"}
+	default:
+		renderer = &render.TextRenderer{}
+	}
+
+	b := &bytes.Buffer{}
+	if err := renderer.Render(r.Context(), b, diffs); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.Bytes())
+}
+
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.accept(w, r, []string{mediaJSON}); !ok {
+		return
+	}
+
+	req := PatchRequest{}
+	if !s.decode(w, r, &req) {
+		return
+	}
+
+	if req.Document == nil {
+		writeError(w, http.StatusBadRequest, "document is required")
+		return
+	}
+
+	if (req.Diffs == nil) == (req.Patch == nil) {
+		writeError(w, http.StatusBadRequest, "either diffs or patch is required")
+		return
+	}
+
+	doc, err := jsondoc.Decode(bytes.NewReader(req.Document))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var diffs []diff.Diff
+	if req.Patch != nil {
+		diffs, err = jsondoc.DecodeJSONPatch(doc, req.Patch)
+	} else {
+		diffs, err = codec.DecodeJSONDiffs(req.Diffs)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := jsondoc.Patch(r.Context(), doc, diffs)
+	if err != nil {
+		conflictErr := &patcher.ConflictError{}
+		if errors.As(err, &conflictErr) {
+			resp := ErrorResponse{Error: err.Error()}
+			for _, c := range conflictErr.Conflicts {
+				resp.Conflicts = append(resp.Conflicts, ConflictResponse{Field: c.Field, Expected: c.Expected, Actual: c.Actual})
+			}
+
+			writeJSON(w, http.StatusConflict, resp)
+			return
+		}
+
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+//accept checks the method and negotiates the media type of the response from the offers
+func (s *Server) accept(w http.ResponseWriter, r *http.Request, offers []string) (string, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", r.Method))
+		return "", false
+	}
+
+	mediaType, ok := negotiate(r.Header.Get("Accept"), offers)
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, fmt.Sprintf("the supported media types are %s", strings.Join(offers, ", ")))
+		return "", false
+	}
+
+	return mediaType, true
+}
+
+//decode reads the JSON body of the request within the size limit
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != mediaJSON {
+			writeError(w, http.StatusUnsupportedMediaType, "the request body should be application/json")
+			return false
+		}
+	}
+
+	if r.ContentLength > s.maxBodySize() {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body is larger than %d bytes", s.maxBodySize()))
+		return false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodySize()))
+	decoder.UseNumber()
+	err := decoder.Decode(v)
+	if err == nil {
+		if _, tokenErr := decoder.Token(); tokenErr != io.EOF {
+			err = fmt.Errorf("unexpected data after the request body")
+		}
+	}
+
+	if err != nil {
+		maxBytesErr := &http.MaxBytesError{}
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the request body is larger than %d bytes", s.maxBodySize()))
+			return false
+		}
+
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return false
+	}
+
+	return true
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+//negotiate returns the offer which matches the Accept header with the highest quality,
+//the first offer is returned when the header is empty
+func negotiate(accept string, offers []string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, mr := range ranges {
+		for _, offer := range offers {
+			if mr.mediaType == offer || mr.mediaType == "*/*" ||
+				(strings.HasSuffix(mr.mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mr.mediaType, "*"))) {
+				return offer, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	writeEncoded(w, status, mediaJSON, v)
+}
+
+func writeEncoded(w http.ResponseWriter, status int, mediaType string, v interface{}) {
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message})
+}
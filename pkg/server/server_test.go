@@ -0,0 +1,189 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/server"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		headers         map[string]string
+		body            string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			"succeed when check the health",
+			http.MethodGet, "/healthz", nil, "",
+			http.StatusOK, "application/json",
+			`{"status":"ok"}`,
+		}, {
+			"succeed when write the JSON diffs by default",
+			http.MethodPost, "/diff", nil,
+			`{"name": "config", "old": {"debug": false, "port": 80}, "new": {"debug": true, "port": 80}}`,
+			http.StatusOK, "application/json",
+			`[{"change_type":"changed","object_type":"config","object_id":"","field":"debug","old":false,"new":true}]`,
+		}, {
+			"succeed when write the JSON Patch",
+			http.MethodPost, "/diff?test=true", map[string]string{"Accept": "application/json-patch+json", "Content-Type": "application/json; charset=utf-8"},
+			`{"old": {"tags": ["a", "b"]}, "new": {"tags": ["a"]}}`,
+			http.StatusOK, "application/json-patch+json",
+			`[{"op":"test","path":"/tags/1","value":"b"},{"op":"remove","path":"/tags/1"}]`,
+		}, {
+			"succeed when write the text by the quality",
+			http.MethodPost, "/diff", map[string]string{"Accept": "application/xml, text/markdown;q=0.5, text/plain;q=0.8"},
+			`{"old": {"a": 1}, "new": {"a": 2}}`,
+			http.StatusOK, "text/plain; charset=utf-8",
+			"~ document\n  ~ a: 1 -> 2",
+		}, {
+			"succeed when write the Markdown by the wildcard",
+			http.MethodPost, "/diff", map[string]string{"Accept": "text/markdown, */*;q=0.1"},
+			`{"old": {"a": 1}, "new": {"a": 2}}`,
+			http.StatusOK, "text/markdown; charset=utf-8",
+			"### document\n\n| Change | Path | Old | New |\n| --- | --- | --- | --- |\n| ~ | `a` | `1` | `2` |",
+		}, {
+			"succeed when write the empty diffs",
+			http.MethodPost, "/diff", map[string]string{"Accept": "application/*"},
+			`{"old": [1, 2], "new": [1, 2.0]}`,
+			http.StatusOK, "application/json",
+			`[]`,
+		}, {
+			"succeed when apply the JSON diffs",
+			http.MethodPost, "/patch", nil,
+			`{"document": {"debug": false, "tags": ["a"]}, "diffs": [{"change_type": "changed", "field": "debug", "old": false, "new": true}, {"change_type": "new", "field": "tags.1", "new": "b"}]}`,
+			http.StatusOK, "application/json",
+			`{"debug":true,"tags":["a","b"]}`,
+		}, {
+			"succeed when apply the JSON Patch",
+			http.MethodPost, "/patch", nil,
+			`{"document": {"name": "app"}, "patch": [{"op": "replace", "path": "/name", "value": "web"}]}`,
+			http.StatusOK, "application/json",
+			`{"name":"web"}`,
+		}, {
+			"succeed when write the diffs of the large integers",
+			http.MethodPost, "/diff", nil,
+			`{"old": {"id": 9007199254740993, "a": 1}, "new": {"id": 9007199254740992, "a": 1.0}}`,
+			http.StatusOK, "application/json",
+			`[{"change_type":"changed","object_type":"document","object_id":"","field":"id","old":9007199254740993,"new":9007199254740992}]`,
+		}, {
+			"succeed when keep the large integers which are not patched",
+			http.MethodPost, "/patch", nil,
+			`{"document": {"id": 9007199254740993, "a": 1}, "patch": [{"op": "replace", "path": "/a", "value": 9007199254740995}]}`,
+			http.StatusOK, "application/json",
+			`{"a":9007199254740995,"id":9007199254740993}`,
+		}, {
+			"succeed when check the large integers of the JSON diffs",
+			http.MethodPost, "/patch", nil,
+			`{"document": {"id": 9007199254740993}, "diffs": [{"change_type": "changed", "field": "id", "old": 9007199254740993, "new": 9007199254740995}]}`,
+			http.StatusOK, "application/json",
+			`{"id":9007199254740995}`,
+		}, {
+			"failed when the patch is conflicted",
+			http.MethodPost, "/patch", nil,
+			`{"document": {"name": "api"}, "patch": [{"op": "test", "path": "/name", "value": "app"}, {"op": "replace", "path": "/name", "value": "web"}]}`,
+			http.StatusConflict, "application/json",
+			`{"error":"conflict on patch: name (expected 'app' got 'api')","conflicts":[{"field":"name","expected":"app","actual":"api"}]}`,
+		}, {
+			"failed when both diffs and patch are given",
+			http.MethodPost, "/patch", nil,
+			`{"document": {}, "diffs": [], "patch": []}`,
+			http.StatusBadRequest, "application/json",
+			`{"error":"either diffs or patch is required"}`,
+		}, {
+			"failed when the documents are missing",
+			http.MethodPost, "/diff", nil,
+			`{"old": {}}`,
+			http.StatusBadRequest, "application/json",
+			`{"error":"old and new documents are required"}`,
+		}, {
+			"failed when the body is invalid",
+			http.MethodPost, "/diff", nil,
+			`{"old": {}, "new": {}} {}`,
+			http.StatusBadRequest, "application/json",
+			`{"error":"invalid request body: unexpected data after the request body"}`,
+		}, {
+			"failed when the media type is not acceptable",
+			http.MethodPost, "/diff", map[string]string{"Accept": "application/xml, text/plain;q=0"},
+			`{"old": {}, "new": {}}`,
+			http.StatusNotAcceptable, "application/json",
+			`{"error":"the supported media types are application/json, application/json-patch+json, text/plain, text/markdown, text/html"}`,
+		}, {
+			"failed when the content type is unsupported",
+			http.MethodPost, "/diff", map[string]string{"Content-Type": "text/plain"},
+			`{"old": {}, "new": {}}`,
+			http.StatusUnsupportedMediaType, "application/json",
+			`{"error":"the request body should be application/json"}`,
+		}, {
+			"failed when the method is not allowed",
+			http.MethodGet, "/diff", nil, "",
+			http.StatusMethodNotAllowed, "application/json",
+			`{"error":"method GET is not allowed"}`,
+		}, {
+			"failed when the path is not found",
+			http.MethodGet, "/merge", nil, "",
+			http.StatusNotFound, "text/plain; charset=utf-8",
+			"404 page not found",
+		},
+	}
+
+	s := server.New(server.Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("ServeHTTP() content type = %v, want %v", got, tt.wantContentType)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("ServeHTTP() body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServer_MaxBodySize(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength int64
+		body          string
+		wantStatus    int
+	}{
+		{"succeed when the body is within the limit", 0, `{"old": "a", "new": "b"}`, http.StatusOK},
+		{"failed when the content length is too large", 0, `{"old": "` + strings.Repeat("a", 64) + `", "new": ""}`, http.StatusRequestEntityTooLarge},
+		{"failed when the body without content length is too large", -1, `{"old": "` + strings.Repeat("a", 64) + `", "new": ""}`, http.StatusRequestEntityTooLarge},
+	}
+
+	s := server.New(server.Options{MaxBodySize: 32})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/diff", strings.NewReader(tt.body))
+			if tt.contentLength != 0 {
+				r.ContentLength = tt.contentLength
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package table
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//ColumnType is the type which the cells of a column are compared as
+type ColumnType string
+
+const (
+	//String compares the cells as they are
+	String ColumnType = "string"
+
+	//Number compares the cells as the floating-point numbers, e.g. 1.50 is equal to 1.5
+	Number ColumnType = "number"
+
+	//Date compares the cells as the instants of time which are parsed by the date layouts
+	Date ColumnType = "date"
+)
+
+//DefaultDateLayouts is the layouts which the date cells are parsed by when Options.DateLayouts is empty
+var DefaultDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+//Table is the header and the rows of a CSV file
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+//Options is the options of comparing two tables
+type Options struct {
+	//Keys is the columns which identify a row, the key value is the object ID of the diffs.
+	//The values of multiple keys are encoded as a JSON array into the object ID, e.g. ["us","1"]
+	Keys []string
+
+	//Types is the type of the columns by name, the other columns are compared as String
+	Types map[string]ColumnType
+
+	//DateLayouts is the layouts which the Date cells are parsed by, the default is DefaultDateLayouts
+	DateLayouts []string
+}
+
+func (o Options) dateLayouts() []string {
+	if len(o.DateLayouts) == 0 {
+		return DefaultDateLayouts
+	}
+
+	return o.DateLayouts
+}
+
+//ReadCSV reads a table from CSV, the first record is the header and every row should have the same number of cells
+func ReadCSV(r io.Reader) (*Table, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error on read CSV Error : %s", err.Error())
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("error on read CSV Error : header is not found")
+	}
+
+	return &Table{Header: records[0], Rows: records[1:]}, nil
+}
+
+//Compare is used to compare two tables which rows are matched by the key columns. The removed and the changed rows
+//are given in the order of the old table, followed by the added rows in the order of the new table.
+//The changed cells are compared by the column types and given with their original text, while the added or
+//removed rows are given as a map of the cells by column. The columns which exist on one of the tables are
+//given as the added or removed cells of the matched rows
+func Compare(ctx context.Context, old, new *Table, opts Options) ([]diff.Diff, error) {
+	if len(opts.Keys) == 0 {
+		return nil, fmt.Errorf("error on compare tables Error : key columns are required")
+	}
+
+	for column, columnType := range opts.Types {
+		switch columnType {
+		case String, Number, Date:
+		default:
+			return nil, fmt.Errorf("error on compare tables Error : unsupported type %s of column %s", columnType, column)
+		}
+	}
+
+	oldRows, err := indexRows(old, opts.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("error on index old table Error : %s", err.Error())
+	}
+
+	newRows, err := indexRows(new, opts.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("error on index new table Error : %s", err.Error())
+	}
+
+	oldColumns := columnIndexes(old.Header)
+	newColumns := columnIndexes(new.Header)
+	columns := append([]string{}, old.Header...)
+	for _, column := range new.Header {
+		if _, ok := oldColumns[column]; !ok {
+			columns = append(columns, column)
+		}
+	}
+
+	diffs := []diff.Diff{}
+	for oldRow := range old.Rows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		key := oldRows.keys[oldRow]
+		newRow, ok := newRows.rows[key]
+		if !ok {
+			diffs = append(diffs, diff.Diff{ChangeType: diff.Removed, ObjectID: key, Old: cells(old.Header, old.Rows[oldRow])})
+			continue
+		}
+
+		for _, column := range columns {
+			oldIndex, inOld := oldColumns[column]
+			newIndex, inNew := newColumns[column]
+			switch {
+			case !inOld:
+				diffs = append(diffs, diff.Diff{ChangeType: diff.New, ObjectID: key, Field: diff.JoinField(column), New: new.Rows[newRow][newIndex]})
+			case !inNew:
+				diffs = append(diffs, diff.Diff{ChangeType: diff.Removed, ObjectID: key, Field: diff.JoinField(column), Old: old.Rows[oldRow][oldIndex]})
+			default:
+				oldCell, newCell := old.Rows[oldRow][oldIndex], new.Rows[newRow][newIndex]
+				equal, err := opts.equal(column, oldCell, newCell)
+				if err != nil {
+					return nil, fmt.Errorf("error on compare row %s Error : %s", key, err.Error())
+				}
+
+				if !equal {
+					diffs = append(diffs, diff.Diff{ChangeType: diff.Changed, ObjectID: key, Field: diff.JoinField(column), Old: oldCell, New: newCell})
+				}
+			}
+		}
+	}
+
+	for newRow := range new.Rows {
+		key := newRows.keys[newRow]
+		if _, ok := oldRows.rows[key]; !ok {
+			diffs = append(diffs, diff.Diff{ChangeType: diff.New, ObjectID: key, New: cells(new.Header, new.Rows[newRow])})
+		}
+	}
+
+	return diffs, nil
+}
+
+//equal compares two cells of the column by its type, the empty cells are only equal to each other
+func (o Options) equal(column, old, new string) (bool, error) {
+	if old == new {
+		return true, nil
+	}
+
+	columnType := o.Types[column]
+	if columnType == "" || columnType == String || old == "" || new == "" {
+		return false, nil
+	}
+
+	switch columnType {
+	case Number:
+		oldNumber, err := strconv.ParseFloat(strings.TrimSpace(old), 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number %q of column %s", old, column)
+		}
+
+		newNumber, err := strconv.ParseFloat(strings.TrimSpace(new), 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number %q of column %s", new, column)
+		}
+
+		return oldNumber == newNumber, nil
+	default:
+		oldDate, err := o.parseDate(old)
+		if err != nil {
+			return false, fmt.Errorf("invalid date %q of column %s", old, column)
+		}
+
+		newDate, err := o.parseDate(new)
+		if err != nil {
+			return false, fmt.Errorf("invalid date %q of column %s", new, column)
+		}
+
+		return oldDate.Equal(newDate), nil
+	}
+}
+
+func (o Options) parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range o.dateLayouts() {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("no layout matches %s", s)
+}
+
+//rowIndex is the rows of a table by their key
+type rowIndex struct {
+	rows map[string]int
+	keys []string
+}
+
+func indexRows(t *Table, keys []string) (*rowIndex, error) {
+	columns := columnIndexes(t.Header)
+	keyIndexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		i, ok := columns[key]
+		if !ok {
+			return nil, fmt.Errorf("key column %s is not found", key)
+		}
+		keyIndexes = append(keyIndexes, i)
+	}
+
+	index := &rowIndex{rows: make(map[string]int, len(t.Rows)), keys: make([]string, len(t.Rows))}
+	for i, row := range t.Rows {
+		if len(row) != len(t.Header) {
+			return nil, fmt.Errorf("row %d has %d cells, expected %d", i+1, len(row), len(t.Header))
+		}
+
+		values := make([]string, 0, len(keyIndexes))
+		for _, k := range keyIndexes {
+			values = append(values, row[k])
+		}
+
+		key, err := rowKey(values)
+		if err != nil {
+			return nil, err
+		}
+
+		if previous, ok := index.rows[key]; ok {
+			return nil, fmt.Errorf("duplicate key %s on row %d and %d", key, previous+1, i+1)
+		}
+
+		index.rows[key] = i
+		index.keys[i] = key
+	}
+
+	return index, nil
+}
+
+//rowKey returns the key of a row, the values of multiple keys are encoded as a JSON array
+//so the values which contain a comma are not mixed up
+func rowKey(values []string) (string, error) {
+	if len(values) == 1 {
+		return values[0], nil
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func columnIndexes(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		if _, ok := columns[column]; !ok {
+			columns[column] = i
+		}
+	}
+
+	return columns
+}
+
+func cells(header, row []string) map[string]interface{} {
+	result := make(map[string]interface{}, len(header))
+	for i, column := range header {
+		result[column] = row[i]
+	}
+
+	return result
+}
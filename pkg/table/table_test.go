@@ -0,0 +1,175 @@
+package table_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/table"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *table.Table
+		wantErr bool
+	}{
+		{
+			"succeed when read the table",
+			"id,name\n1,\"a, b\"\n",
+			&table.Table{Header: []string{"id", "name"}, Rows: [][]string{{"1", "a, b"}}},
+			false,
+		},
+		{"failed when the header is not found", "", nil, true},
+		{"failed when the rows have different number of cells", "id,name\n1\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.ReadCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	types := map[string]table.ColumnType{"amount": table.Number, "paid_at": table.Date}
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		opts    table.Options
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when the rows are added, removed and changed",
+			"id,name,amount,paid_at\n1,a,10.50,2024-01-02\n2,b,20,2024-01-03\n3,c,30,\n",
+			"id,name,amount,paid_at\n3,C,30.0,2024-01-04T00:00:00Z\n4,d,40,\n1,a,10.5,2024-01-02T00:00:00Z\n",
+			table.Options{Keys: []string{"id"}, Types: types},
+			[]diff.Diff{
+				{ChangeType: diff.Removed, ObjectID: "2", Old: map[string]interface{}{"id": "2", "name": "b", "amount": "20", "paid_at": "2024-01-03"}},
+				{ChangeType: diff.Changed, ObjectID: "3", Field: "name", Old: "c", New: "C"},
+				{ChangeType: diff.Changed, ObjectID: "3", Field: "paid_at", Old: "", New: "2024-01-04T00:00:00Z"},
+				{ChangeType: diff.New, ObjectID: "4", New: map[string]interface{}{"id": "4", "name": "d", "amount": "40", "paid_at": ""}},
+			},
+			false,
+		}, {
+			"succeed when compare the cells as string by default",
+			"id,amount\n1,10.50\n",
+			"id,amount\n1,10.5\n",
+			table.Options{Keys: []string{"id"}},
+			[]diff.Diff{{ChangeType: diff.Changed, ObjectID: "1", Field: "amount", Old: "10.50", New: "10.5"}},
+			false,
+		}, {
+			"succeed when match the rows by multiple keys",
+			"region,id,total\neu,1,5\nus,1,6\n",
+			"id,region,total\n1,us,7\n1,eu,5\n",
+			table.Options{Keys: []string{"region", "id"}},
+			[]diff.Diff{{ChangeType: diff.Changed, ObjectID: `["us","1"]`, Field: "total", Old: "6", New: "7"}},
+			false,
+		}, {
+			"succeed when the key values contain comma",
+			"a,b,total\n\"x,y\",z,1\nx,\"y,z\",2\n",
+			"a,b,total\nx,\"y,z\",2\n\"x,y\",z,3\n",
+			table.Options{Keys: []string{"a", "b"}},
+			[]diff.Diff{{ChangeType: diff.Changed, ObjectID: `["x,y","z"]`, Field: "total", Old: "1", New: "3"}},
+			false,
+		}, {
+			"succeed when the column name contains dots",
+			"id,price.usd\n1,10\n",
+			"id,price.usd\n1,11\n",
+			table.Options{Keys: []string{"id"}},
+			[]diff.Diff{{ChangeType: diff.Changed, ObjectID: "1", Field: `price\.usd`, Old: "10", New: "11"}},
+			false,
+		}, {
+			"succeed when the columns are added and removed",
+			"id,a\n1,x\n",
+			"id,b\n1,y\n",
+			table.Options{Keys: []string{"id"}},
+			[]diff.Diff{
+				{ChangeType: diff.Removed, ObjectID: "1", Field: "a", Old: "x"},
+				{ChangeType: diff.New, ObjectID: "1", Field: "b", New: "y"},
+			},
+			false,
+		}, {
+			"succeed when parse the date by the custom layout",
+			"id,paid_at\n1,02/01/2024\n",
+			"id,paid_at\n1,2/1/2024\n",
+			table.Options{Keys: []string{"id"}, Types: types, DateLayouts: []string{"2/1/2006"}},
+			[]diff.Diff{},
+			false,
+		}, {
+			"failed when the number is invalid",
+			"id,amount\n1,ten\n",
+			"id,amount\n1,10\n",
+			table.Options{Keys: []string{"id"}, Types: types},
+			nil,
+			true,
+		}, {
+			"failed when the date is invalid",
+			"id,paid_at\n1,yesterday\n",
+			"id,paid_at\n1,2024-01-02\n",
+			table.Options{Keys: []string{"id"}, Types: types},
+			nil,
+			true,
+		}, {
+			"failed when the key is duplicated",
+			"id\n1\n1\n",
+			"id\n1\n",
+			table.Options{Keys: []string{"id"}},
+			nil,
+			true,
+		}, {
+			"failed when the key column is not found",
+			"id\n1\n",
+			"code\n1\n",
+			table.Options{Keys: []string{"id"}},
+			nil,
+			true,
+		}, {
+			"failed when the keys are empty",
+			"id\n1\n",
+			"id\n1\n",
+			table.Options{},
+			nil,
+			true,
+		}, {
+			"failed when the type is unsupported",
+			"id\n1\n",
+			"id\n1\n",
+			table.Options{Keys: []string{"id"}, Types: map[string]table.ColumnType{"id": "bool"}},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := table.ReadCSV(strings.NewReader(tt.old))
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+			new, err := table.ReadCSV(strings.NewReader(tt.new))
+			if err != nil {
+				t.Fatalf("ReadCSV() error = %v", err)
+			}
+
+			got, err := table.Compare(context.Background(), old, new, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
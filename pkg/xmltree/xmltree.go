@@ -0,0 +1,311 @@
+package xmltree
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+)
+
+//Element is a generic XML element. The namespaces are resolved by encoding/xml, and the comments,
+//processing instructions and namespace declarations are not kept
+type Element struct {
+	Name     xml.Name
+	Attrs    map[string]string
+	Children []*Element
+
+	//Text is the trimmed character data of the element, the segments between the children are joined by a space
+	Text string
+}
+
+//Options is the options of comparing two XML documents
+type Options struct {
+	//IDAttribute identifies the child elements, e.g. id. The children which have the attribute are matched
+	//by its value, and the others are matched by their position among the siblings of the same name
+	IDAttribute string
+}
+
+//Parse reads a single XML document into the element tree
+func Parse(r io.Reader) (*Element, error) {
+	decoder := xml.NewDecoder(r)
+
+	var root *Element
+	stack := []*Element{}
+	texts := [][]string{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error on parse XML Error : %s", err.Error())
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && len(stack) == 0 {
+				return nil, fmt.Errorf("error on parse XML Error : unexpected element %s after the root element", t.Name.Local)
+			}
+
+			e := &Element{Name: t.Name, Attrs: map[string]string{}}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				e.Attrs[qualifiedName(attr.Name)] = attr.Value
+			}
+
+			if len(stack) == 0 {
+				root = e
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, e)
+			}
+			stack = append(stack, e)
+			texts = append(texts, []string{})
+		case xml.EndElement:
+			e := stack[len(stack)-1]
+			e.Text = strings.Join(texts[len(texts)-1], " ")
+			stack = stack[:len(stack)-1]
+			texts = texts[:len(texts)-1]
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+
+			if text := strings.TrimSpace(string(t)); text != "" {
+				texts[len(texts)-1] = append(texts[len(texts)-1], text)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("error on parse XML Error : root element is not found")
+	}
+
+	return root, nil
+}
+
+//String returns the compact XML of the element, its attributes are ordered by name
+func (e *Element) String() string {
+	b := &bytes.Buffer{}
+	e.write(b)
+
+	return b.String()
+}
+
+func (e *Element) write(b *bytes.Buffer) {
+	b.WriteString("<" + e.Name.Local)
+	for _, name := range sortedKeys(e.Attrs) {
+		b.WriteString(" " + name + `="`)
+		xml.EscapeText(b, []byte(e.Attrs[name]))
+		b.WriteString(`"`)
+	}
+
+	if e.Text == "" && len(e.Children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+
+	b.WriteString(">")
+	xml.EscapeText(b, []byte(e.Text))
+	for _, c := range e.Children {
+		c.write(b)
+	}
+	b.WriteString("</" + e.Name.Local + ">")
+}
+
+//Compare is used to compare two XML documents. The fields of the diffs are XPath-like, e.g. /order/item[2]/@qty
+//or /order/item[@id='a1']/text(), and the values of the added or removed elements are their XML.
+//The fields are kept as a single segment of the diff field, so their dots are escaped, e.g. /order/item[@id='a\.1'].
+//The attributes are compared as sets, so their order is not significant, and the duplicate IDs of the siblings are rejected.
+//The names of the elements and the attributes are prefixed by their namespaces in braces, e.g. /order/{urn:x}item[1]
+func Compare(ctx context.Context, old, new *Element, opts Options) ([]diff.Diff, error) {
+	if old == nil || new == nil {
+		return nil, fmt.Errorf("error on compare XML Error : documents cannot be nil")
+	}
+
+	path := "/" + qualifiedName(old.Name)
+	if old.Name != new.Name {
+		return []diff.Diff{{ChangeType: diff.Changed, Field: "/", Old: old.String(), New: new.String()}}, nil
+	}
+
+	diffs := []diff.Diff{}
+	if err := compareElement(ctx, path, old, new, opts, &diffs); err != nil {
+		return nil, err
+	}
+
+	return diffs, nil
+}
+
+func compareElement(ctx context.Context, path string, old, new *Element, opts Options, diffs *[]diff.Diff) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	names := sortedKeys(old.Attrs)
+	for _, name := range sortedKeys(new.Attrs) {
+		if _, ok := old.Attrs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldValue, inOld := old.Attrs[name]
+		newValue, inNew := new.Attrs[name]
+		field := diff.JoinField(path + "/@" + name)
+		switch {
+		case !inOld:
+			*diffs = append(*diffs, diff.Diff{ChangeType: diff.New, Field: field, New: newValue})
+		case !inNew:
+			*diffs = append(*diffs, diff.Diff{ChangeType: diff.Removed, Field: field, Old: oldValue})
+		case oldValue != newValue:
+			*diffs = append(*diffs, diff.Diff{ChangeType: diff.Changed, Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	if old.Text != new.Text {
+		*diffs = append(*diffs, diff.Diff{ChangeType: diff.Changed, Field: diff.JoinField(path + "/text()"), Old: old.Text, New: new.Text})
+	}
+
+	return compareChildren(ctx, path, old.Children, new.Children, opts, diffs)
+}
+
+//child is a child element with its step of the path
+type child struct {
+	element *Element
+	step    string
+}
+
+//compareChildren matches the children of the same name by the ID attribute, or by their position among the siblings
+//which have no ID attribute. The names are compared in the order of their first appearance in the old element
+func compareChildren(ctx context.Context, path string, old, new []*Element, opts Options, diffs *[]diff.Diff) error {
+	oldGroups, oldNames := groupChildren(old)
+	newGroups, newNames := groupChildren(new)
+
+	names := oldNames
+	for _, name := range newNames {
+		if _, ok := oldGroups[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		oldChildren := steps(oldGroups[name], opts.IDAttribute)
+		newChildren := steps(newGroups[name], opts.IDAttribute)
+
+		oldByStep, err := indexSteps(path, oldChildren)
+		if err != nil {
+			return err
+		}
+
+		newByStep, err := indexSteps(path, newChildren)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range oldChildren {
+			field := path + "/" + c.step
+			match, ok := newByStep[c.step]
+			if !ok {
+				*diffs = append(*diffs, diff.Diff{ChangeType: diff.Removed, Field: diff.JoinField(field), Old: c.element.String()})
+				continue
+			}
+
+			if err := compareElement(ctx, field, c.element, match, opts, diffs); err != nil {
+				return err
+			}
+		}
+
+		for _, c := range newChildren {
+			if _, ok := oldByStep[c.step]; !ok {
+				*diffs = append(*diffs, diff.Diff{ChangeType: diff.New, Field: diff.JoinField(path + "/" + c.step), New: c.element.String()})
+			}
+		}
+	}
+
+	return nil
+}
+
+//indexSteps indexes the siblings by their steps, the siblings which have the same ID are rejected
+func indexSteps(path string, siblings []child) (map[string]*Element, error) {
+	index := make(map[string]*Element, len(siblings))
+	for _, c := range siblings {
+		if _, ok := index[c.step]; ok {
+			return nil, fmt.Errorf("error on compare XML Error : duplicate ID on %s/%s", path, c.step)
+		}
+		index[c.step] = c.element
+	}
+
+	return index, nil
+}
+
+//groupChildren groups the children by name, the names are given in the order of their first appearance
+func groupChildren(children []*Element) (map[xml.Name][]*Element, []xml.Name) {
+	groups := map[xml.Name][]*Element{}
+	names := []xml.Name{}
+	for _, c := range children {
+		if _, ok := groups[c.Name]; !ok {
+			names = append(names, c.Name)
+		}
+		groups[c.Name] = append(groups[c.Name], c)
+	}
+
+	return groups, names
+}
+
+//steps gives the path steps of the siblings of the same name, which is name[@id='value'] when the ID attribute exists,
+//otherwise it is the 1-based position among the siblings without the ID attribute, e.g. name[2]
+func steps(siblings []*Element, idAttribute string) []child {
+	result := make([]child, 0, len(siblings))
+	position := 0
+	for _, e := range siblings {
+		if id, ok := e.Attrs[idAttribute]; ok && idAttribute != "" {
+			result = append(result, child{e, fmt.Sprintf("%s[@%s=%s]", qualifiedName(e.Name), idAttribute, quote(id))})
+			continue
+		}
+
+		position++
+		result = append(result, child{e, fmt.Sprintf("%s[%d]", qualifiedName(e.Name), position)})
+	}
+
+	return result
+}
+
+//quote quotes the value as an XPath string literal, the value which contains both quotes is joined by concat(),
+//e.g. concat('a', "'", 'b"c')
+func quote(s string) string {
+	switch {
+	case !strings.Contains(s, "'"):
+		return "'" + s + "'"
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`
+	default:
+		return "concat('" + strings.Join(strings.Split(s, "'"), `', "'", '`) + "')"
+	}
+}
+
+//qualifiedName is the local name of an element or an attribute, which is prefixed by its namespace in braces when it has one
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return "{" + name.Space + "}" + name.Local
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
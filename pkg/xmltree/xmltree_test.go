@@ -0,0 +1,222 @@
+package xmltree_test
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/haritsfahreza/libra/pkg/diff"
+	"github.com/haritsfahreza/libra/pkg/xmltree"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *xmltree.Element
+		wantErr bool
+	}{
+		{
+			"succeed when parse the element tree",
+			`<?xml version="1.0"?>
+<!-- order -->
+<order xmlns:x="urn:x" number="1" x:channel="web">
+  <item id="a1">Pen</item>
+  gift <note/> wrapped
+</order>`,
+			&xmltree.Element{
+				Name:  xml.Name{Local: "order"},
+				Attrs: map[string]string{"number": "1", "{urn:x}channel": "web"},
+				Children: []*xmltree.Element{
+					{Name: xml.Name{Local: "item"}, Attrs: map[string]string{"id": "a1"}, Text: "Pen"},
+					{Name: xml.Name{Local: "note"}, Attrs: map[string]string{}},
+				},
+				Text: "gift wrapped",
+			},
+			false,
+		},
+		{"failed when the root element is not found", `<?xml version="1.0"?>`, nil, true},
+		{"failed when the element is not closed", "<order><item></order>", nil, true},
+		{"failed when there are more than one root element", "<order/><order/>", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := xmltree.Parse(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElement_String(t *testing.T) {
+	e := parse(t, `<item qty="1" id="a&amp;b">  Pen &lt;blue&gt; <tag/></item>`)
+	want := `<item id="a&amp;b" qty="1">Pen &lt;blue&gt;<tag/></item>`
+	if got := e.String(); got != want {
+		t.Errorf("Element.String() = %v, want %v", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := `<order number="1" status="open">
+  <customer>Ann</customer>
+  <item id="a1" qty="1">Pen</item>
+  <item id="b2" qty="2">Ink</item>
+  <note>first</note>
+</order>`
+	new := `<order status="paid" number="1" channel="web">
+  <customer>Anna</customer>
+  <item qty="3" id="b2">Ink</item>
+  <item id="c3" qty="1">Pad</item>
+</order>`
+
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		opts    xmltree.Options
+		want    []diff.Diff
+		wantErr bool
+	}{
+		{
+			"succeed when the children are matched by position",
+			old,
+			new,
+			xmltree.Options{},
+			[]diff.Diff{
+				{ChangeType: diff.New, Field: "/order/@channel", New: "web"},
+				{ChangeType: diff.Changed, Field: "/order/@status", Old: "open", New: "paid"},
+				{ChangeType: diff.Changed, Field: "/order/customer[1]/text()", Old: "Ann", New: "Anna"},
+				{ChangeType: diff.Changed, Field: "/order/item[1]/@id", Old: "a1", New: "b2"},
+				{ChangeType: diff.Changed, Field: "/order/item[1]/@qty", Old: "1", New: "3"},
+				{ChangeType: diff.Changed, Field: "/order/item[1]/text()", Old: "Pen", New: "Ink"},
+				{ChangeType: diff.Changed, Field: "/order/item[2]/@id", Old: "b2", New: "c3"},
+				{ChangeType: diff.Changed, Field: "/order/item[2]/@qty", Old: "2", New: "1"},
+				{ChangeType: diff.Changed, Field: "/order/item[2]/text()", Old: "Ink", New: "Pad"},
+				{ChangeType: diff.Removed, Field: "/order/note[1]", Old: "<note>first</note>"},
+			},
+			false,
+		},
+		{
+			"succeed when the children are matched by the ID attribute",
+			old,
+			new,
+			xmltree.Options{IDAttribute: "id"},
+			[]diff.Diff{
+				{ChangeType: diff.New, Field: "/order/@channel", New: "web"},
+				{ChangeType: diff.Changed, Field: "/order/@status", Old: "open", New: "paid"},
+				{ChangeType: diff.Changed, Field: "/order/customer[1]/text()", Old: "Ann", New: "Anna"},
+				{ChangeType: diff.Removed, Field: "/order/item[@id='a1']", Old: `<item id="a1" qty="1">Pen</item>`},
+				{ChangeType: diff.Changed, Field: "/order/item[@id='b2']/@qty", Old: "2", New: "3"},
+				{ChangeType: diff.New, Field: "/order/item[@id='c3']", New: `<item id="c3" qty="1">Pad</item>`},
+				{ChangeType: diff.Removed, Field: "/order/note[1]", Old: "<note>first</note>"},
+			},
+			false,
+		},
+		{
+			"succeed when the children without the ID attribute are matched by position",
+			`<list><item id="it's">a</item><item>b</item><item>c</item></list>`,
+			`<list><item>b</item><item id="it's">A</item></list>`,
+			xmltree.Options{IDAttribute: "id"},
+			[]diff.Diff{
+				{ChangeType: diff.Changed, Field: `/list/item[@id="it's"]/text()`, Old: "a", New: "A"},
+				{ChangeType: diff.Removed, Field: "/list/item[2]", Old: "<item>c</item>"},
+			},
+			false,
+		},
+		{
+			"succeed when the ID contains dots",
+			`<order><item id="a.1" qty="1"/></order>`,
+			`<order><item id="a.1" qty="2"/></order>`,
+			xmltree.Options{IDAttribute: "id"},
+			[]diff.Diff{{ChangeType: diff.Changed, Field: `/order/item[@id='a\.1']/@qty`, Old: "1", New: "2"}},
+			false,
+		},
+		{
+			"succeed when the ID contains both quotes",
+			`<order><item id="a'b&quot;c" qty="1"/></order>`,
+			`<order><item id="a'b&quot;c" qty="2"/></order>`,
+			xmltree.Options{IDAttribute: "id"},
+			[]diff.Diff{{ChangeType: diff.Changed, Field: `/order/item[@id=concat('a', "'", 'b"c')]/@qty`, Old: "1", New: "2"}},
+			false,
+		},
+		{
+			"succeed when the children of the same local name are in different namespaces",
+			`<order xmlns:x="urn:x"><item>a</item><x:item>b</x:item></order>`,
+			`<order xmlns:x="urn:x"><x:item>B</x:item><item>a</item></order>`,
+			xmltree.Options{},
+			[]diff.Diff{{ChangeType: diff.Changed, Field: "/order/{urn:x}item[1]/text()", Old: "b", New: "B"}},
+			false,
+		},
+		{
+			"succeed when the documents are equal regardless of the attribute order and whitespace",
+			`<order a="1" b="2"><item>Pen</item></order>`,
+			"<order b=\"2\" a=\"1\">\n  <item>\n    Pen\n  </item>\n</order>",
+			xmltree.Options{},
+			[]diff.Diff{},
+			false,
+		},
+		{
+			"succeed when the root elements are different",
+			`<order/>`,
+			`<invoice/>`,
+			xmltree.Options{},
+			[]diff.Diff{{ChangeType: diff.Changed, Field: "/", Old: "<order/>", New: "<invoice/>"}},
+			false,
+		},
+		{
+			"failed when the old IDs are duplicated",
+			`<order><item id="a1">Pen</item><item id="a1">Ink</item></order>`,
+			`<order><item id="a1">Pen</item></order>`,
+			xmltree.Options{IDAttribute: "id"},
+			nil,
+			true,
+		},
+		{
+			"failed when the new IDs are duplicated",
+			`<order><item id="a1">Pen</item></order>`,
+			`<order><item id="a1">Pen</item><item id="a1">Ink</item></order>`,
+			xmltree.Options{IDAttribute: "id"},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := xmltree.Compare(context.Background(), parse(t, tt.old), parse(t, tt.new), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := xmltree.Compare(ctx, parse(t, "<a/>"), parse(t, "<a/>"), xmltree.Options{}); err == nil {
+		t.Errorf("Compare() error = %v, wantErr %v", err, true)
+	}
+}
+
+func parse(t *testing.T, s string) *xmltree.Element {
+	t.Helper()
+
+	e, err := xmltree.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	return e
+}